@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func (a *App) newRolloutRestartCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restart (TYPE/NAME | POD | -l SELECTOR)",
+		Short: "Restart the pods of a deployment, statefulset or daemonset",
+		Long: "Roll every pod of a workload by stamping its pod template with the " + kube.RestartedAtAnnotation + " annotation, like kubectl. " +
+			"The workload is given directly or found from a pod or a label selector through the pods' owners. " +
+			"Then the pods are watched until they have all been replaced and are ready.",
+		Example: "  kubepeek rollout restart deploy/api\n  kubepeek rollout restart api-7d9f-abc\n  kubepeek rollout restart -l tier=backend --timeout 10m",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			owners, err := a.owners(cmd.Context(), args)
+			if err != nil {
+				return err
+			}
+			workloads, err := a.workloads()
+			if err != nil {
+				return err
+			}
+			at := time.Now()
+			for _, o := range owners {
+				if err := workloads.Restart(cmd.Context(), o, at); err != nil {
+					return err
+				}
+				fmt.Printf("%s restarted\n", ownerRef(o))
+			}
+			for _, o := range owners {
+				selector, err := kube.PodSelector(o)
+				if err != nil {
+					return err
+				}
+				if err := a.settle(cmd.Context(), o, selector, kube.RestartSettled(at), "the pods of "+ownerRef(o)+" to be replaced"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	a.addSettleFlags(cmd)
+	return cmd
+}
+
+func (a *App) newScaleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scale (TYPE/NAME | POD | -l SELECTOR) --replicas N",
+		Short: "Set the number of replicas of a workload",
+		Long: "Set the replicas of a workload through its scale subresource, which works for any scalable kind, custom resources included. " +
+			"The workload is given directly or found from a pod or a label selector through the pods' owners. " +
+			"Then the pods are watched until there are as many as asked for, all ready.",
+		Example: "  kubepeek scale deploy/api --replicas 5\n  kubepeek scale sts/db --replicas 0 --wait=false\n  kubepeek scale api-7d9f-abc --replicas 2",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.flags.replicas < 0 {
+				return errors.New("--replicas is required and cannot be negative")
+			}
+			owners, err := a.owners(cmd.Context(), args)
+			if err != nil {
+				return err
+			}
+			workloads, err := a.workloads()
+			if err != nil {
+				return err
+			}
+			replicas := int32(a.flags.replicas)
+			selectors := make([]string, len(owners))
+			for i, o := range owners {
+				previous, selector, err := workloads.Scale(cmd.Context(), o, replicas)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s scaled from %d to %d\n", ownerRef(o), previous, replicas)
+				selectors[i] = selector
+			}
+			for i, o := range owners {
+				what := fmt.Sprintf("%s to have %d ready pods", ownerRef(o), replicas)
+				if err := a.settle(cmd.Context(), o, selectors[i], kube.ScaleSettled(replicas), what); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&a.flags.replicas, "replicas", -1, "The new number of replicas")
+	a.addSettleFlags(cmd)
+	return cmd
+}
+
+func (a *App) newResizeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resize (POD | TYPE/NAME | -l SELECTOR) [-c CONTAINER] [--requests LIST] [--limits LIST]",
+		Short: "Change the resources of running pods in place",
+		Long: "Change the CPU and memory of a container in running pods without restarting them, through the pods' resize subresource " +
+			"(in-place pod vertical scaling). Every pod of the target is resized; the workload's template is left alone, so new pods start with the old resources. " +
+			"Then the pods are watched until the kubelet has applied the change.",
+		Example: "  kubepeek resize api-7d9f-abc --requests cpu=500m,memory=256Mi\n  kubepeek resize deploy/api -c app --limits cpu=2",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			requests, err := kube.ParseResourceList(a.flags.requests)
+			if err != nil {
+				return fmt.Errorf("--requests: %w", err)
+			}
+			limits, err := kube.ParseResourceList(a.flags.limits)
+			if err != nil {
+				return fmt.Errorf("--limits: %w", err)
+			}
+			if requests == nil && limits == nil {
+				return errors.New("give the new resources with --requests or --limits, e.g. --requests cpu=500m")
+			}
+			var target string
+			if len(args) == 1 {
+				target = args[0]
+			}
+			resolver, err := a.podResolver()
+			if err != nil {
+				return err
+			}
+			pods, err := resolver.Resolve(cmd.Context(), a.flags.namespace, target, a.flags.selector)
+			if err != nil {
+				return err
+			}
+
+			var names []string
+			containers := map[string]string{}
+			ns := pods[0].Namespace
+			for i := range pods {
+				pod := &pods[i]
+				c, note, err := kube.ExecContainer(pod, a.flags.container)
+				if err != nil {
+					return err
+				}
+				if note != "" {
+					fmt.Fprintln(os.Stderr, note)
+				}
+				if err := kube.ResizePod(cmd.Context(), a.Client, pod, c, requests, limits); err != nil {
+					return fmt.Errorf("resize pod %s: %w", pod.Name, err)
+				}
+				fmt.Printf("pod/%s container %s resized\n", pod.Name, c)
+				if !slices.Contains(names, pod.Name) {
+					names = append(names, pod.Name)
+				}
+				containers[pod.Namespace+"/"+pod.Name] = c
+				if pod.Namespace != ns {
+					// The pods span namespaces, watch them all
+					ns = ""
+				}
+			}
+			if !a.flags.wait {
+				return nil
+			}
+			return a.watchUntil(cmd.Context(), kube.RunOpts{
+				Namespace: ns,
+				Names:     kube.NameMatch{Exact: names},
+				Until:     kube.ResizeSettled(containers),
+			}, "the resize to be applied")
+		},
+	}
+	cmd.Flags().StringVarP(&a.flags.container, "container", "c", "", "Container to resize; defaults to the "+kube.DefaultContainerAnnotation+" annotation or the first container")
+	cmd.Flags().StringVar(&a.flags.requests, "requests", "", "New requests, e.g. cpu=500m,memory=256Mi")
+	cmd.Flags().StringVar(&a.flags.limits, "limits", "", "New limits, e.g. cpu=1,memory=512Mi")
+	a.addSettleFlags(cmd)
+	return cmd
+}
+
+func (a *App) addSettleFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&a.flags.selector, "selector", "l", "", "Find the workload from the pods matching this label selector")
+	cmd.Flags().BoolVar(&a.flags.wait, "wait", true, "Watch the pods until the change has settled")
+	cmd.Flags().DurationVar(&a.flags.waitTimeout, "timeout", 5*time.Minute, "Give up waiting after this long; 0 waits forever")
+}
+
+// owners finds the workloads behind the target argument or --selector.
+func (a *App) owners(ctx context.Context, args []string) ([]*unstructured.Unstructured, error) {
+	var target string
+	if len(args) == 1 {
+		target = args[0]
+	}
+	resolver, err := a.podResolver()
+	if err != nil {
+		return nil, err
+	}
+	return resolver.Owners(ctx, a.flags.namespace, target, a.flags.selector)
+}
+
+func (a *App) workloads() (kube.Workloads, error) {
+	dyn, err := a.Provider.DynamicClient()
+	if err != nil {
+		return kube.Workloads{}, err
+	}
+	mapper, err := a.Provider.RESTMapper()
+	if err != nil {
+		return kube.Workloads{}, err
+	}
+	return kube.Workloads{Dynamic: dyn, Mapper: mapper}, nil
+}
+
+func ownerRef(o *unstructured.Unstructured) string {
+	return strings.ToLower(o.GetKind()) + "/" + o.GetName()
+}
+
+// settle watches the pods of a workload, those matching selector, until
+// until holds.
+func (a *App) settle(ctx context.Context, owner *unstructured.Unstructured, selector string, until func([]v1.Pod) (bool, error), what string) error {
+	if !a.flags.wait {
+		return nil
+	}
+	if selector == "" {
+		return fmt.Errorf("cannot wait for %s: it reports no pod selector, pass --wait=false", ownerRef(owner))
+	}
+	return a.watchUntil(ctx, kube.RunOpts{
+		Namespace: owner.GetNamespace(),
+		ListOpts:  kube.ListOpts{LabelSelector: selector},
+		Until:     until,
+	}, what)
+}
+
+// watchUntil shows the pods live until opts.Until holds, the timeout hits
+// or the user interrupts.
+func (a *App) watchUntil(ctx context.Context, opts kube.RunOpts, what string) error {
+	if a.flags.waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.flags.waitTimeout)
+		defer cancel()
+	}
+	settled := false
+	until := opts.Until
+	opts.Until = func(pods []v1.Pod) (bool, error) {
+		ok, err := until(pods)
+		settled = ok
+		return ok, err
+	}
+	opts.Watch = true
+	ctrl := kube.Controller{
+		Source:         kube.ClientGoSource{Client: a.Client},
+		CurrentPrinter: kube.NewLiveTablePrinter(os.Stdout),
+	}
+	if err := ctrl.Run(ctx, opts); err != nil {
+		return err
+	}
+	switch {
+	case settled:
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("timed out after %s waiting for %s", a.flags.waitTimeout, what)
+	case ctx.Err() != nil:
+		return nil
+	}
+	return fmt.Errorf("the watch ended before %s", what)
+}
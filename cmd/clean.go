@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+)
+
+func (a *App) newCleanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Delete leftover resources",
+	}
+	cmd.AddCommand(a.newCleanPodsCmd())
+	return cmd
+}
+
+func (a *App) newCleanPodsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pods",
+		Short: "Delete evicted, failed and completed pods",
+		Long: "Delete pods that have finished: Evicted, OOMKilled, Error, Completed and the like, with the reasons `kubepeek get pods` shows. " +
+			"Running and pending pods are never touched. The pods found are counted by namespace and reason, then deleted in batches once you confirm, or with --yes. " +
+			"--dry-run lists them instead.",
+		Example: "  kubepeek clean pods -A --reasons Evicted,OOMKilled --older-than 24h\n" +
+			"  kubepeek clean pods -n batch --owner Job/nightly-* --dry-run\n" +
+			"  kubepeek clean pods -A --reasons Failed --owner none --yes",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			opts := kube.CleanOpts{OlderThan: a.flags.olderThan, Reasons: a.flags.reasons, Owners: a.flags.owners}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			actionOpts := kube.PodActionOpts{GracePeriod: -1}
+			switch a.flags.dryRun {
+			case "none":
+			case "server":
+				actionOpts.DryRun = true
+			case "client":
+			default:
+				return fmt.Errorf("invalid --dry-run %q: want none, server or client", a.flags.dryRun)
+			}
+
+			list, err := kube.ClientGoSource{Client: a.Client}.List(ctx, a.flags.namespace, kube.ListOpts{
+				LabelSelector: a.flags.selector,
+				FieldSelector: kube.CleanFieldSelector,
+			})
+			if err != nil {
+				return err
+			}
+			cands := kube.FindCleanable(list.Items, opts)
+			if len(cands) == 0 {
+				fmt.Println("No finished pods to clean.")
+				return nil
+			}
+			if err := kube.PrintCleanCounts(os.Stdout, kube.CountCleanable(cands)); err != nil {
+				return err
+			}
+			if a.flags.dryRun == "client" {
+				fmt.Println()
+				if err := kube.PrintCleanCandidates(os.Stdout, cands); err != nil {
+					return err
+				}
+				fmt.Printf("Dry run: %d pods would be deleted.\n", len(cands))
+				return nil
+			}
+			if !a.flags.yes && !actionOpts.DryRun {
+				ok, err := confirm(os.Stdin, os.Stdout, fmt.Sprintf("Delete %d pods?", len(cands)))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return errors.New("aborted, nothing was deleted")
+				}
+			}
+
+			pods := make([]v1.Pod, len(cands))
+			for i, c := range cands {
+				pods[i] = c.Pod
+			}
+			results := kube.DeleteInBatches(ctx, a.Client, pods, a.flags.batchSize, actionOpts, func(done, total int) {
+				fmt.Fprintf(os.Stderr, "Deleted %d/%d\n", done, total)
+			})
+			var failed []kube.PodActionResult
+			for _, r := range results {
+				if r.Failed() {
+					failed = append(failed, r)
+				}
+			}
+			if len(failed) == 0 {
+				if actionOpts.DryRun {
+					fmt.Printf("Server dry run: %d pods would be deleted.\n", len(results))
+				} else {
+					fmt.Printf("Deleted %d pods.\n", len(results))
+				}
+				return nil
+			}
+			if err := kube.PrintPodActionResults(os.Stdout, "Deleted", failed); err != nil {
+				return err
+			}
+			return fmt.Errorf("could not delete %d of %d pods", len(failed), len(results))
+		},
+	}
+
+	cmd.Flags().StringVarP(&a.flags.selector, "selector", "l", "", "Only pods matching this label selector")
+	cmd.Flags().DurationVar(&a.flags.olderThan, "older-than", 0, "Only pods that finished at least this long ago, e.g. 24h")
+	cmd.Flags().StringSliceVar(&a.flags.reasons, "reasons", nil, "Only pods with these reasons (Evicted, OOMKilled, Error, Completed...) or phases (Failed, Succeeded)")
+	cmd.Flags().StringSliceVar(&a.flags.owners, "owner", nil, "Only pods controlled by these owners: KIND, KIND/NAME (NAME may be a glob), or none for pods without one")
+	cmd.Flags().IntVar(&a.flags.batchSize, "batch-size", 50, "Pods to delete at once")
+	cmd.Flags().BoolVarP(&a.flags.yes, "yes", "y", false, "Do not ask for confirmation")
+	cmd.Flags().StringVar(&a.flags.dryRun, "dry-run", "none", "none; client to list the pods instead of deleting them; server to have the apiserver validate each delete without persisting it. A bare --dry-run is client, so give server as --dry-run=server")
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = "client"
+
+	return cmd
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+// loadConfig reads the config file and applies its defaults to the flags
+// of cmd that were not given on the command line. Commands marked with
+// rawConfigAnnotation get the file as it is, unchecked and without
+// defaults, so a broken file can be fixed.
+func (a *App) loadConfig(cmd *cobra.Command) error {
+	if cmd.Annotations[rawConfigAnnotation] == "true" {
+		cfg, err := kube.ReadConfig(kube.DefaultConfigPath())
+		a.config = cfg
+		return err
+	}
+	cfg, err := kube.LoadConfig(kube.DefaultConfigPath())
+	if err != nil {
+		return err
+	}
+	a.config = cfg
+	for _, name := range slices.Sorted(maps.Keys(cfg.Defaults)) {
+		if err := checkGuardFlag(name); err != nil {
+			return err
+		}
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		if err := f.Value.Set(cfg.Defaults[name]); err != nil {
+			return fmt.Errorf("config: defaults.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// rawConfigAnnotation marks the commands that show or change the config
+// file itself.
+const rawConfigAnnotation = "kubepeek/raw-config"
+
+// guardFlags skip a confirmation or a safety check. A default would do
+// that on every run without it showing on the command line, so the config
+// file cannot set them.
+var guardFlags = []string{"yes", "all", "force", "dry-run", "grace-period"}
+
+func checkGuardFlag(name string) error {
+	if slices.Contains(guardFlags, name) {
+		return fmt.Errorf("config: defaults.%s is not allowed: --%s must be given on the command line", name, name)
+	}
+	return nil
+}
+
+func (a *App) newViewCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "view [NAME [FLAGS...]]",
+		Short: "Run a saved view from the config file",
+		Long: "Run a view saved under `views` in the config file (" + kube.DefaultConfigPath() + ", or $KUBEPEEK_CONFIG). " +
+			"Flags after the name are passed on and override the view, e.g. `kubepeek view oncall -w`. Without a name, list the views.",
+		// Flags belong to the command the view runs
+		DisableFlagParsing: true,
+		Annotations:        map[string]string{offlineAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return printViews(a.config)
+			}
+			if args[0] == "-h" || args[0] == "--help" {
+				return cmd.Help()
+			}
+			v, ok := a.config.Views[args[0]]
+			if !ok {
+				return fmt.Errorf("no view %q in %s", args[0], kube.DefaultConfigPath())
+			}
+			// A fresh app, so nothing parsed for this command carries over
+			run, err := NewApp()
+			if err != nil {
+				return err
+			}
+			run.root.SetArgs(append(viewArgs(v), args[1:]...))
+			return run.root.ExecuteContext(cmd.Context())
+		},
+	}
+}
+
+// viewArgs turns a view into the command line it stands for. Several
+// namespaces are listed across all of them and narrowed by a filter.
+func viewArgs(v kube.View) []string {
+	args := []string{"get", v.ResourceOrDefault()}
+	filter := v.Filter
+	switch {
+	case len(v.Namespaces) == 1 && v.Namespaces[0] == "*":
+		args = append(args, "--all-namespaces")
+	case len(v.Namespaces) == 1:
+		args = append(args, "--namespace", v.Namespaces[0])
+	case len(v.Namespaces) > 1:
+		args = append(args, "--all-namespaces")
+		quoted := make([]string, len(v.Namespaces))
+		for i, ns := range v.Namespaces {
+			quoted[i] = regexp.QuoteMeta(ns)
+		}
+		inNamespaces := "namespace =~ " + strconv.Quote(strings.Join(quoted, "|"))
+		if filter != "" {
+			filter = inNamespaces + " && (" + filter + ")"
+		} else {
+			filter = inNamespaces
+		}
+	}
+	if v.Selector != "" {
+		args = append(args, "--selector", v.Selector)
+	}
+	if v.FieldSelector != "" {
+		args = append(args, "--field-selector", v.FieldSelector)
+	}
+	if filter != "" {
+		args = append(args, "--filter", filter)
+	}
+	if len(v.Columns) > 0 {
+		args = append(args, "--columns", strings.Join(v.Columns, ","))
+	}
+	if v.Output != "" {
+		args = append(args, "--output", v.Output)
+	}
+	return args
+}
+
+func printViews(cfg *kube.Config) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCOMMAND")
+	for _, name := range slices.Sorted(maps.Keys(cfg.Views)) {
+		fmt.Fprintf(w, "%s\tkubepeek %s\n", name, strings.Join(quoteArgs(viewArgs(cfg.Views[name])), " "))
+	}
+	return w.Flush()
+}
+
+func quoteArgs(args []string) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = a
+		if strings.ContainsAny(a, " \"'*|&()!<>") {
+			out[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+	}
+	return out
+}
+
+func (a *App) newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show or change the config file",
+		Long:  "The config file (" + kube.DefaultConfigPath() + ", or $KUBEPEEK_CONFIG) holds flag defaults and saved views.",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:         "view",
+		Short:       "Print the config file",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{offlineAnnotation: "true", rawConfigAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := yaml.Marshal(a.config)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "Change one setting",
+		Long: "Change one setting of the config file. KEY is defaults.FLAG for a flag default, e.g. `defaults.output json`, " +
+			"or views.NAME.FIELD for a view, with FIELD one of resource, namespaces, selector, fieldSelector, filter, columns and output; " +
+			"lists are comma-separated. An empty VALUE removes the setting.",
+		Example:     "  kubepeek config set defaults.refresh-interval 500ms\n  kubepeek config set views.oncall.namespaces shop,payments\n  kubepeek config set views.oncall.filter 'restarts > 3'",
+		Args:        cobra.ExactArgs(2),
+		Annotations: map[string]string{offlineAnnotation: "true", rawConfigAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+			if name, ok := strings.CutPrefix(key, "defaults."); ok && value != "" {
+				if err := checkDefault(name, value); err != nil {
+					return err
+				}
+			}
+			if err := a.config.Set(key, value); err != nil {
+				return err
+			}
+			return a.config.Save(kube.DefaultConfigPath())
+		},
+	})
+
+	return cmd
+}
+
+// checkDefault makes sure some command has the flag and accepts the value,
+// by setting it on a fresh command tree.
+func checkDefault(name, value string) error {
+	if err := checkGuardFlag(name); err != nil {
+		return err
+	}
+	fresh, err := NewApp()
+	if err != nil {
+		return err
+	}
+	var flag *pflag.Flag
+	var find func(*cobra.Command)
+	find = func(c *cobra.Command) {
+		if flag == nil {
+			if flag = c.Flags().Lookup(name); flag == nil {
+				flag = c.PersistentFlags().Lookup(name)
+			}
+		}
+		for _, sub := range c.Commands() {
+			find(sub)
+		}
+	}
+	find(fresh.root)
+	if flag == nil {
+		return fmt.Errorf("config: no command has a --%s flag", name)
+	}
+	if err := flag.Value.Set(value); err != nil {
+		return fmt.Errorf("config: defaults.%s: %w", name, err)
+	}
+	return nil
+}
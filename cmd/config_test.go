@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+)
+
+func TestViewArgs(t *testing.T) {
+	tests := []struct {
+		view kube.View
+		want string
+	}{
+		{kube.View{}, "get pods"},
+		{kube.View{Resource: "deployments", Namespaces: []string{"*"}, Selector: "app=api"}, "get deployments --all-namespaces --selector app=api"},
+		{kube.View{Namespaces: []string{"shop"}, Columns: []string{"NAME", "NODE"}, Output: "json"}, "get pods --namespace shop --columns NAME,NODE --output json"},
+		{
+			kube.View{Namespaces: []string{"shop", "kube.system"}, Filter: "restarts > 3"},
+			`get pods --all-namespaces --filter namespace =~ "shop|kube\\.system" && (restarts > 3)`,
+		},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(viewArgs(tt.view), " "); got != tt.want {
+			t.Errorf("viewArgs(%+v) = %s, want %s", tt.view, got, tt.want)
+		}
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("KUBEPEEK_CONFIG", path)
+
+	a, err := NewApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"config", "set", "defaults.output", "json"},
+		{"config", "set", "defaults.refresh-interval", "1s"},
+		{"config", "set", "views.oncall.filter", "restarts > 3"},
+	} {
+		a.root.SetArgs(args)
+		if err := a.root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	for _, bad := range [][]string{
+		{"config", "set", "defaults.no-such-flag", "1"},
+		{"config", "set", "defaults.refresh-interval", "soon"},
+		{"config", "set", "defaults.yes", "true"},
+		{"config", "set", "defaults.all", "true"},
+	} {
+		a.root.SetArgs(bad)
+		if err := a.root.Execute(); err == nil {
+			t.Errorf("%v: error = nil", bad)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "refresh-interval: 1s") {
+		t.Errorf("config file = %s", data)
+	}
+
+	// Defaults fill in flags not given on the command line
+	a, err = NewApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, rest, err := a.root.Find([]string{"get", "pods", "--output", "table"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ParseFlags(rest); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.loadConfig(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if a.flags.output != "table" || a.flags.refresh != time.Second {
+		t.Errorf("output = %q, refresh-interval = %v; want the flag to win and the default to fill in", a.flags.output, a.flags.refresh)
+	}
+	if _, ok := a.config.Views["oncall"]; !ok {
+		t.Error("view oncall not loaded")
+	}
+
+	// Flags that skip confirmations cannot come from the file
+	if err := os.WriteFile(path, []byte("defaults:\n  \"yes\": \"true\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a, err = NewApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, _, err = a.root.Find([]string{"delete", "pods"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.loadConfig(cmd); err == nil || a.flags.yes {
+		t.Errorf("loadConfig(defaults.yes) error = %v, yes = %v", err, a.flags.yes)
+	}
+}
+
+func TestConfigFixBrokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("KUBEPEEK_CONFIG", path)
+	if err := os.WriteFile(path, []byte("views:\n  oncall:\n    resource: nodes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) error {
+		a, err := NewApp()
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.root.SetArgs(args)
+		return a.root.Execute()
+	}
+	if err := run("view"); err == nil {
+		t.Error("view with a broken config file: error = nil")
+	}
+	for _, args := range [][]string{
+		{"config", "view"},
+		{"config", "set", "views.oncall.resource", "pods"},
+		{"view"},
+	} {
+		if err := run(args...); err != nil {
+			t.Errorf("%v: %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newCpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cp SRC DST",
+		Short: "Copy files and directories to and from a container",
+		Long: "Copy a file or directory between the local machine and a container. The container side is written TARGET:PATH, where TARGET is a pod " +
+			"or an owner or service (deploy/api, svc/web) to pick a pod from. Files keep their permissions and times. " +
+			"Copying streams a tar archive over exec, so the image needs tar; without it single files are copied with cat or tee.",
+		Example: "  kubepeek cp api-7d9f-abc:/var/log/app.log ./app.log\n  kubepeek cp ./config deploy/api:/etc/app -c app\n  kubepeek cp -n shop svc/web:/usr/share/nginx/html ./html",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, err := kube.ParseCopySpec(args[0])
+			if err != nil {
+				return err
+			}
+			dst, err := kube.ParseCopySpec(args[1])
+			if err != nil {
+				return err
+			}
+			if src.Remote() == dst.Remote() {
+				return errors.New("one of SRC and DST must be in a container (TARGET:PATH) and the other local")
+			}
+			remote := src
+			if dst.Remote() {
+				remote = dst
+			}
+
+			resolver, err := a.podResolver()
+			if err != nil {
+				return err
+			}
+			pod, err := resolver.Pick(cmd.Context(), a.flags.namespace, remote.Target, "")
+			if err != nil {
+				return err
+			}
+			container, note, err := kube.ExecContainer(pod, a.flags.container)
+			if err != nil {
+				return err
+			}
+			if note != "" {
+				fmt.Fprintln(os.Stderr, note)
+			}
+			if err := kube.CheckExecutable(pod, container); err != nil {
+				return err
+			}
+			config, err := a.Provider.RESTConfig()
+			if err != nil {
+				return err
+			}
+
+			copier := kube.Copier{
+				Exec:     kube.RemoteExecutor{Config: config, Client: a.Client},
+				Progress: os.Stderr,
+				Log:      os.Stderr,
+			}
+			target := kube.CopyPod{Namespace: pod.Namespace, Pod: pod.Name, Container: container}
+			if dst.Remote() {
+				return copier.Upload(cmd.Context(), target, src.Path, dst.Path)
+			}
+			return copier.Download(cmd.Context(), target, src.Path, dst.Path)
+		},
+	}
+
+	cmd.Flags().StringVarP(&a.flags.container, "container", "c", "", "Container name; defaults to the "+kube.DefaultContainerAnnotation+" annotation or the first container")
+
+	return cmd
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (a *App) newDebugCmd() *cobra.Command {
+	presets := make([]string, 0, len(kube.DebugImages))
+	for name := range kube.DebugImages {
+		presets = append(presets, name)
+	}
+	slices.Sort(presets)
+
+	cmd := &cobra.Command{
+		Use:   "debug (POD | TYPE/NAME | -l SELECTOR) [--image IMAGE] [--target CONTAINER] [--copy-to NAME] [-it] [-- COMMAND [ARGS...]]",
+		Short: "Debug a pod with an ephemeral container or a copy",
+		Long: "Add an ephemeral debug container to a running pod, sharing the process namespace of the target container, and attach to it. " +
+			"The image is one of the presets (" + strings.Join(presets, ", ") + ") or any image; the command defaults to sh. " +
+			"Ephemeral containers cannot be removed: the debug container stays in the pod, stopped, until the pod goes away.\n\n" +
+			"With --copy-to the pod is left alone and a copy is created instead, with the target container running the debug command in place of its own " +
+			"and without probes, which is how to get into a container that crashes on start. --image then replaces the container's image. " +
+			"The copy has no labels or owners, so no controller or service picks it up; delete it when done.",
+		Example: "  kubepeek debug api-7d9f-abc -it\n  kubepeek debug deploy/api --image netshoot -it -- bash\n" +
+			"  kubepeek debug api-7d9f-abc --target sidecar -- ps aux\n  kubepeek debug deploy/api --copy-to api-debug -it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			var command []string
+			if dash >= 0 {
+				args, command = args[:dash], args[dash:]
+			}
+			if len(args) > 1 {
+				return fmt.Errorf("expected one target, got %d", len(args))
+			}
+			if a.flags.tty && !a.flags.stdin {
+				return errors.New("-t needs -i")
+			}
+			var target string
+			if len(args) == 1 {
+				target = args[0]
+			}
+
+			ctx := cmd.Context()
+			resolver, err := a.podResolver()
+			if err != nil {
+				return err
+			}
+			pod, err := resolver.Pick(ctx, a.flags.namespace, target, a.flags.selector)
+			if err != nil {
+				return err
+			}
+			container, note, err := kube.ExecContainer(pod, a.flags.target)
+			if err != nil {
+				return err
+			}
+			if note != "" {
+				fmt.Fprintln(os.Stderr, note)
+			}
+			opts := kube.DebugOpts{
+				Image:   a.flags.image,
+				Target:  container,
+				Command: command,
+				Stdin:   a.flags.stdin,
+				TTY:     a.flags.tty,
+			}
+			if opts.TTY && !kube.IsTerminal(os.Stdin) {
+				fmt.Fprintln(os.Stderr, "Unable to use a TTY: input is not a terminal")
+				opts.TTY = false
+			}
+
+			if a.flags.copyTo != "" {
+				if !cmd.Flags().Changed("image") {
+					opts.Image = ""
+				}
+				return a.debugCopy(ctx, pod, opts)
+			}
+			name, err := kube.AddEphemeralContainer(ctx, a.Client, pod, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Debug container %s (%s) added to pod %s, sharing processes with %s\n", name, kube.DebugImage(opts.Image), pod.Name, container)
+			return a.debugAttach(ctx, pod.Namespace, pod.Name, name, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&a.flags.image, "image", "busybox", "Debug image: "+strings.Join(presets, " or ")+", or any image name")
+	cmd.Flags().StringVar(&a.flags.target, "target", "", "Container to debug; defaults to the "+kube.DefaultContainerAnnotation+" annotation or the first container")
+	cmd.Flags().StringVar(&a.flags.copyTo, "copy-to", "", "Debug a copy of the pod with this name instead of the pod itself")
+	cmd.Flags().StringVarP(&a.flags.selector, "selector", "l", "", "Pick the pod from the ones matching this label selector")
+	cmd.Flags().BoolVarP(&a.flags.stdin, "stdin", "i", false, "Attach to the debug container and pass stdin to it")
+	cmd.Flags().BoolVarP(&a.flags.tty, "tty", "t", false, "Allocate a TTY; needs -i")
+	cmd.Flags().DurationVar(&a.flags.waitTimeout, "timeout", 5*time.Minute, "Give up waiting for the debug container to start after this long")
+
+	return cmd
+}
+
+// debugCopy creates the debug copy of the pod and attaches to it.
+func (a *App) debugCopy(ctx context.Context, pod *v1.Pod, opts kube.DebugOpts) error {
+	cp, err := kube.DebugCopy(pod, a.flags.copyTo, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := a.Client.CoreV1().Pods(cp.Namespace).Create(ctx, cp, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("create pod %s: %w", cp.Name, err)
+	}
+	fmt.Fprintf(os.Stderr, "Pod %s created from %s; delete it with: kubepeek delete pods %s -n %s\n", cp.Name, pod.Name, cp.Name, cp.Namespace)
+	return a.debugAttach(ctx, cp.Namespace, cp.Name, opts.Target, opts)
+}
+
+// debugAttach waits for the debug container to run, then attaches to it
+// with -i or says how to reach it without.
+func (a *App) debugAttach(ctx context.Context, ns, pod, container string, opts kube.DebugOpts) error {
+	waitCtx := ctx
+	if a.flags.waitTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, a.flags.waitTimeout)
+		defer cancel()
+	}
+	if err := kube.WaitForContainer(waitCtx, a.Client, ns, pod, container); err != nil {
+		return err
+	}
+	if !opts.Stdin {
+		fmt.Fprintf(os.Stderr, "Container %s has started; see its output with: kubectl logs %s -c %s -n %s\n", container, pod, container, ns)
+		return nil
+	}
+	config, err := a.Provider.RESTConfig()
+	if err != nil {
+		return err
+	}
+	execOpts := kube.ExecOpts{
+		Namespace: ns,
+		Pod:       pod,
+		Container: container,
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}
+	if opts.TTY {
+		restore, ok, err := kube.MakeRaw(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if ok {
+			defer restore()
+			execOpts.TTY = true
+			execOpts.Resize = kube.NewTerminalSizeQueue(ctx, os.Stdout)
+		}
+	}
+	return kube.RemoteExecutor{Config: config, Client: a.Client}.Attach(ctx, execOpts)
+}
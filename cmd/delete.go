@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// podAction is a bulk action on pods: delete or evict.
+type podAction struct {
+	verb string // delete
+	done string // Deleted
+	long string
+	run  func(context.Context, kubernetes.Interface, []v1.Pod, kube.PodActionOpts) []kube.PodActionResult
+}
+
+var (
+	deleteAction = podAction{
+		verb: "delete",
+		done: "Deleted",
+		long: "Delete the pods that `kubepeek get pods` would list with the same arguments and flags. ",
+		run:  kube.DeletePods,
+	}
+	evictAction = podAction{
+		verb: "evict",
+		done: "Evicted",
+		long: "Evict the pods that `kubepeek get pods` would list with the same arguments and flags, through the Eviction API " +
+			"so that PodDisruptionBudgets are honoured; pods a budget protects are reported and left running. ",
+		run: kube.EvictPods,
+	}
+)
+
+func (a *App) newDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources",
+	}
+	cmd.AddCommand(a.newPodActionCmd(deleteAction))
+	return cmd
+}
+
+func (a *App) newEvictCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "evict",
+		Short: "Evict pods, honouring PodDisruptionBudgets",
+	}
+	cmd.AddCommand(a.newPodActionCmd(evictAction))
+	return cmd
+}
+
+func (a *App) newPodActionCmd(action podAction) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pods [NAME|PATTERN...]",
+		Short: strings.ToUpper(action.verb[:1]) + action.verb[1:] + " pods",
+		Long: action.long + "The pods are listed first and nothing happens until you confirm, or pass --yes. " +
+			"Without names, a selector or a filter, --all is needed to " + action.verb + " every pod of the namespace.",
+		Example: fmt.Sprintf("  kubepeek %[1]s pods -l app=api --filter 'restarts > 10'\n  kubepeek %[1]s pods 'batch-*' -n jobs --yes\n  kubepeek %[1]s pods -A --problems --dry-run=server", action.verb),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			opts := kube.PodActionOpts{GracePeriod: a.flags.gracePeriod, Force: a.flags.force}
+			switch a.flags.dryRun {
+			case "none":
+			case "server":
+				opts.DryRun = true
+			case "client":
+			default:
+				return fmt.Errorf("invalid --dry-run %q: want none, server or client", a.flags.dryRun)
+			}
+			if a.flags.force && a.flags.gracePeriod > 0 {
+				return errors.New("--force kills pods right away; it cannot be combined with a --grace-period")
+			}
+			names, err := kube.ParseNameMatch(args, a.flags.regex)
+			if err != nil {
+				return err
+			}
+			filter, err := a.podFilter()
+			if err != nil {
+				return err
+			}
+			if names.IsZero() && filter == nil && a.flags.selector == "" && a.flags.fieldSelector == "" && !a.flags.deleteAll {
+				scope := "namespace " + a.flags.namespace
+				if a.flags.namespace == "" {
+					scope = "the cluster"
+				}
+				return fmt.Errorf("this would %s every pod in %s; name pods, narrow with -l, --field-selector or --filter, or pass --all", action.verb, scope)
+			}
+
+			pods, err := kube.SelectPods(ctx, kube.ClientGoSource{Client: a.Client}, a.flags.namespace, kube.ListOpts{
+				LabelSelector: a.flags.selector,
+				FieldSelector: a.flags.fieldSelector,
+			}, names, filter)
+			if err != nil {
+				return err
+			}
+			if len(pods) == 0 {
+				fmt.Println("No pods matched.")
+				return nil
+			}
+
+			noun := "pods"
+			if len(pods) == 1 {
+				noun = "pod"
+			}
+			fmt.Printf("%d %s will be %s:\n", len(pods), noun, strings.ToLower(action.done))
+			if err := kube.NewTablePrinter(os.Stdout).Print(kube.ToRows(pods)); err != nil {
+				return err
+			}
+			if a.flags.dryRun == "client" {
+				fmt.Printf("Dry run: no pods were %s.\n", strings.ToLower(action.done))
+				return nil
+			}
+			if !a.flags.yes && !opts.DryRun {
+				ok, err := confirm(os.Stdin, os.Stdout, fmt.Sprintf("%s %d %s?", strings.ToUpper(action.verb[:1])+action.verb[1:], len(pods), noun))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return errors.New("aborted, nothing was " + strings.ToLower(action.done))
+				}
+			}
+
+			results := action.run(ctx, a.Client, pods, opts)
+			if err := kube.PrintPodActionResults(os.Stdout, action.done, results); err != nil {
+				return err
+			}
+			failed := 0
+			for _, r := range results {
+				if r.Failed() {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("could not %s %d of %d pods", action.verb, failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&a.flags.selector, "selector", "l", "", "Selector (label query) to filter on, e.g. -l app=api")
+	cmd.Flags().StringVar(&a.flags.fieldSelector, "field-selector", "", "Selector (field query) to filter on, e.g. --field-selector status.phase=Failed")
+	cmd.Flags().StringVar(&a.flags.filter, "filter", "", `Client-side filter expression, e.g. 'restarts > 3 && node =~ "worker-.*"'`)
+	cmd.Flags().StringVar(&a.flags.regex, "regex", "", "Only pods whose name matches this regular expression")
+	cmd.Flags().BoolVar(&a.flags.problems, "problems", false, "Only pods that are not running and ready or successfully completed")
+	cmd.Flags().BoolVar(&a.flags.deleteAll, "all", false, "Allow selecting every pod of the namespace (or of the cluster with -A)")
+	cmd.Flags().BoolVarP(&a.flags.yes, "yes", "y", false, "Do not ask for confirmation")
+	cmd.Flags().StringVar(&a.flags.dryRun, "dry-run", "none", "none; client to only preview the pods; server to also have the apiserver validate each request without persisting it")
+	cmd.Flags().Int64Var(&a.flags.gracePeriod, "grace-period", -1, "Seconds each pod has to terminate gracefully; -1 uses the pod's own terminationGracePeriodSeconds")
+	cmd.Flags().BoolVar(&a.flags.force, "force", false, "Kill the pods right away, without waiting for them to terminate gracefully")
+
+	return cmd
+}
+
+// confirm asks a yes/no question; anything but y or yes, or no input at
+// all, is no.
+func confirm(in io.Reader, out io.Writer, prompt string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if err == io.EOF {
+		fmt.Fprintln(out)
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := map[string]bool{"y\n": true, "YES\n": true, " yes ": true, "n\n": false, "\n": false, "": false, "sure\n": false}
+	for in, want := range tests {
+		var out bytes.Buffer
+		got, err := confirm(strings.NewReader(in), &out, "Delete 3 pods?")
+		if err != nil || got != want {
+			t.Errorf("confirm(%q) = %v, %v; want %v", in, got, err, want)
+		}
+		if !strings.HasPrefix(out.String(), "Delete 3 pods? [y/N] ") {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
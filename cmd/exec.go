@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+)
+
+func (a *App) newExecCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec (POD | TYPE/NAME | -l SELECTOR) [-c CONTAINER] [-it | --all] -- COMMAND [ARGS...]",
+		Short: "Run a command in a container",
+		Long: "Run a command in a container of a pod. The pod is given by name, or picked from an owner or service (deploy/api, sts/db, svc/web) " +
+			"or a label selector, preferring ready pods. Without -c the container is the one named by the " + kube.DefaultContainerAnnotation +
+			" annotation, or else the first. With -it the session is interactive and follows terminal resizes. " +
+			"With --all the command runs on every matching pod, a few at a time, and the exit codes are summarised.",
+		Example: "  kubepeek exec api-7d9f-abc -- ls /\n  kubepeek exec deploy/api -it -- sh\n  kubepeek exec -l app=web -c nginx -- nginx -T\n  kubepeek exec --all -l app=api --group -- cat /etc/config",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 || dash == len(args) {
+				return errors.New("a command is required after --, e.g. kubepeek exec deploy/api -- sh")
+			}
+			if dash > 1 {
+				return fmt.Errorf("expected one target before --, got %d", dash)
+			}
+			if a.flags.execAll && (a.flags.stdin || a.flags.tty) {
+				return errors.New("--all runs without stdin; drop -i and -t")
+			}
+			if a.flags.tty && !a.flags.stdin {
+				return errors.New("-t needs -i")
+			}
+			var target string
+			if dash == 1 {
+				target = args[0]
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			resolver, err := a.podResolver()
+			if err != nil {
+				return err
+			}
+			if a.flags.execAll {
+				pods, err := resolver.Resolve(ctx, a.flags.namespace, target, a.flags.selector)
+				if err != nil {
+					return err
+				}
+				return a.execFleet(ctx, pods, args[dash:])
+			}
+			pod, err := resolver.Pick(ctx, a.flags.namespace, target, a.flags.selector)
+			if err != nil {
+				return err
+			}
+			container, note, err := kube.ExecContainer(pod, a.flags.container)
+			if err != nil {
+				return err
+			}
+			if note != "" {
+				fmt.Fprintln(os.Stderr, note)
+			}
+			if err := kube.CheckExecutable(pod, container); err != nil {
+				return err
+			}
+			config, err := a.Provider.RESTConfig()
+			if err != nil {
+				return err
+			}
+
+			opts := kube.ExecOpts{
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				Container: container,
+				Command:   args[dash:],
+				Stdout:    os.Stdout,
+				Stderr:    os.Stderr,
+			}
+			if a.flags.stdin {
+				opts.Stdin = os.Stdin
+			}
+			if a.flags.tty {
+				restore, ok, err := kube.MakeRaw(os.Stdin)
+				if err != nil {
+					return err
+				}
+				if ok {
+					defer restore()
+					opts.TTY = true
+					opts.Resize = kube.NewTerminalSizeQueue(ctx, os.Stdout)
+				} else {
+					fmt.Fprintln(os.Stderr, "Unable to use a TTY: input is not a terminal")
+				}
+			}
+			return kube.RemoteExecutor{Config: config, Client: a.Client}.Exec(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&a.flags.container, "container", "c", "", "Container name; defaults to the "+kube.DefaultContainerAnnotation+" annotation or the first container")
+	cmd.Flags().StringVarP(&a.flags.selector, "selector", "l", "", "Pick the pod from the ones matching this label selector")
+	cmd.Flags().BoolVar(&a.flags.execAll, "all", false, "Run the command on every matching pod instead of one, printing output prefixed by pod")
+	cmd.Flags().IntVar(&a.flags.concurrency, "concurrency", 10, "With --all, the number of pods to run the command on at once")
+	cmd.Flags().BoolVar(&a.flags.group, "group", false, "With --all, wait for every pod and print each distinct output once with the pods that gave it")
+	cmd.Flags().BoolVarP(&a.flags.stdin, "stdin", "i", false, "Pass stdin to the container")
+	cmd.Flags().BoolVarP(&a.flags.tty, "tty", "t", false, "Allocate a TTY; needs -i")
+
+	return cmd
+}
+
+// execFleet runs the command on all pods and fails if it failed on any.
+func (a *App) execFleet(ctx context.Context, pods []v1.Pod, command []string) error {
+	opts := kube.FleetOpts{
+		Command:     command,
+		Container:   a.flags.container,
+		Concurrency: a.flags.concurrency,
+	}
+	if !a.flags.group {
+		opts.Out = os.Stdout
+	}
+	config, err := a.Provider.RESTConfig()
+	if err != nil {
+		return err
+	}
+	results := kube.RunFleet(ctx, kube.RemoteExecutor{Config: config, Client: a.Client}, pods, opts)
+
+	if a.flags.group {
+		err = kube.PrintFleetGroups(os.Stdout, kube.GroupResults(results))
+	} else {
+		err = kube.PrintFleetSummary(os.Stderr, results)
+	}
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Failed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("command failed on %d of %d pods", failed, len(results))
+	}
+	return nil
+}
+
+// podResolver finds pods by name, TYPE/NAME or selector.
+func (a *App) podResolver() (kube.PodResolver, error) {
+	dyn, err := a.Provider.DynamicClient()
+	if err != nil {
+		return kube.PodResolver{}, err
+	}
+	mapper, err := a.Provider.RESTMapper()
+	if err != nil {
+		return kube.PodResolver{}, err
+	}
+	return kube.PodResolver{Pods: kube.ClientGoSource{Client: a.Client}, Dynamic: dyn, Mapper: mapper}, nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newForwardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "forward",
+		Short: "Run port-forward profiles from the config file",
+	}
+	cmd.AddCommand(a.newForwardUpCmd())
+	return cmd
+}
+
+func (a *App) newForwardUpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "up PROFILE",
+		Short: "Start every port-forward of a profile",
+		Long: "Start every port-forward listed under `profiles` in the config file (" + kube.DefaultConfigPath() + ", or $KUBEPEEK_CONFIG) at once, " +
+			"and show a table of their status, last error and bytes transferred until interrupted. " +
+			"Each forward fails over to another running pod like `kubepeek port-forward`, keeping its local ports. " +
+			"Forwards without a namespace use --namespace.",
+		Example: "  kubepeek forward up dev\n  kubepeek forward up dev --address 0.0.0.0",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			specs, ok := a.config.Profiles[args[0]]
+			if !ok {
+				names := slices.Sorted(maps.Keys(a.config.Profiles))
+				if len(names) == 0 {
+					return fmt.Errorf("no profile %q: %s has no profiles", args[0], kube.DefaultConfigPath())
+				}
+				return fmt.Errorf("no profile %q in %s, choose one of: %s", args[0], kube.DefaultConfigPath(), strings.Join(names, ", "))
+			}
+			resolver, err := a.podResolver()
+			if err != nil {
+				return err
+			}
+			config, err := a.Provider.RESTConfig()
+			if err != nil {
+				return err
+			}
+
+			profile := kube.ForwardProfile{
+				Resolver: resolver,
+				// The forwards listen on random ports that the profile's
+				// own listeners relay to
+				Forwarder: kube.RemoteForwarder{Config: config, Client: a.Client, Addresses: []string{"localhost"}},
+				Printer:   kube.NewForwardTablePrinter(os.Stdout),
+				Address:   a.flags.address,
+			}
+			return profile.Run(cmd.Context(), a.flags.namespace, specs)
+		},
+	}
+
+	cmd.Flags().StringVar(&a.flags.address, "address", "localhost", "Local address to listen on")
+
+	return cmd
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (a *App) newCordonCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "cordon NODE",
+		Short:   "Mark a node unschedulable",
+		Long:    "Mark a node unschedulable, so that no new pods are scheduled on it. The pods already running there keep running.",
+		Example: "  kubepeek cordon worker-3",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.cordon(cmd.Context(), args[0], true, false)
+		},
+	}
+}
+
+func (a *App) newUncordonCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "uncordon NODE",
+		Short:   "Mark a node schedulable again",
+		Example: "  kubepeek uncordon worker-3",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.cordon(cmd.Context(), args[0], false, false)
+		},
+	}
+}
+
+func (a *App) cordon(ctx context.Context, node string, unschedulable, dryRun bool) error {
+	changed, err := kube.Cordon(ctx, a.Client, node, unschedulable, dryRun)
+	if err != nil {
+		return err
+	}
+	state := "cordoned"
+	if !unschedulable {
+		state = "uncordoned"
+	}
+	switch {
+	case !changed:
+		fmt.Printf("node/%s already %s\n", node, state)
+	case dryRun:
+		fmt.Printf("node/%s %s (server dry run)\n", node, state)
+	default:
+		fmt.Printf("node/%s %s\n", node, state)
+	}
+	return nil
+}
+
+func (a *App) newDrainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drain NODE",
+		Short: "Cordon a node and evict its pods, honouring PodDisruptionBudgets",
+		Long: "Cordon the node, then evict its pods through the Eviction API. DaemonSet pods and mirror pods are left alone, " +
+			"and pods without a controller are flagged since nothing recreates them. The pods are listed first and nothing happens " +
+			"until you confirm, or pass --yes. Evictions a PodDisruptionBudget blocks are retried every " + kube.DrainRetryInterval.String() +
+			" while the pods of the node are watched live, until they are all gone or --timeout passes.",
+		Example: "  kubepeek drain worker-3\n  kubepeek drain worker-3 --dry-run\n  kubepeek drain worker-3 --yes --timeout 15m --grace-period 30",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			node := args[0]
+			opts := kube.PodActionOpts{GracePeriod: a.flags.gracePeriod}
+			switch a.flags.dryRun {
+			case "none", "client":
+			case "server":
+				opts.DryRun = true
+			default:
+				return fmt.Errorf("invalid --dry-run %q: want none, server or client", a.flags.dryRun)
+			}
+			if _, err := a.Client.CoreV1().Nodes().Get(ctx, node, metav1.GetOptions{}); err != nil {
+				return err
+			}
+
+			pods, err := kube.SelectPods(ctx, kube.ClientGoSource{Client: a.Client}, "", kube.ListOpts{
+				FieldSelector: kube.NodePodsSelector(node),
+			}, kube.NameMatch{}, nil)
+			if err != nil {
+				return err
+			}
+			plan := kube.PlanDrain(pods)
+			if len(pods) > 0 {
+				fmt.Printf("%d of %d pods on node %s will be evicted:\n", len(plan.Evict), len(pods), node)
+				if err := kube.PrintDrainPlan(os.Stdout, plan); err != nil {
+					return err
+				}
+			}
+			if a.flags.dryRun == "client" {
+				fmt.Println("Dry run: the node was not cordoned and no pods were evicted.")
+				return nil
+			}
+			if !a.flags.yes && !opts.DryRun && len(plan.Evict) > 0 {
+				ok, err := confirm(os.Stdin, os.Stdout, fmt.Sprintf("Drain node %s?", node))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return errors.New("aborted, the node was not cordoned")
+				}
+			}
+			if err := a.cordon(ctx, node, true, opts.DryRun); err != nil {
+				return err
+			}
+			if len(plan.Evict) == 0 {
+				fmt.Println("No pods to evict.")
+				return nil
+			}
+			if opts.DryRun {
+				results := kube.EvictPods(ctx, a.Client, plan.Evict, opts)
+				if err := kube.PrintPodActionResults(os.Stdout, "Evicted", results); err != nil {
+					return err
+				}
+				return kube.DrainError(results)
+			}
+			return a.drain(ctx, node, plan, opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&a.flags.yes, "yes", "y", false, "Do not ask for confirmation")
+	cmd.Flags().StringVar(&a.flags.dryRun, "dry-run", "none", "none; client to only preview the pods; server to also have the apiserver validate the cordon and each eviction without persisting them. A bare --dry-run is client, so give server as --dry-run=server")
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = "client"
+	cmd.Flags().Int64Var(&a.flags.gracePeriod, "grace-period", -1, "Seconds each pod has to terminate gracefully; -1 uses the pod's own terminationGracePeriodSeconds")
+	cmd.Flags().DurationVar(&a.flags.waitTimeout, "timeout", 5*time.Minute, "Give up after this long, leaving the node cordoned; 0 waits forever")
+
+	return cmd
+}
+
+// drain evicts the pods in the background while watching the node until
+// they are gone. The watch stops early when an eviction fails for good,
+// and a timeout stops the evictions.
+func (a *App) drain(ctx context.Context, node string, plan kube.DrainPlan, opts kube.PodActionOpts) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan []kube.PodActionResult, 1)
+	go func() {
+		results := kube.EvictWithRetry(ctx, a.Client, plan.Evict, opts, kube.DrainRetryInterval)
+		if kube.DrainError(results) != nil && ctx.Err() == nil {
+			// Nothing will make the rest go, stop watching
+			cancel()
+		}
+		done <- results
+	}()
+
+	err := a.watchUntil(ctx, kube.RunOpts{
+		ListOpts: kube.ListOpts{FieldSelector: kube.NodePodsSelector(node)},
+		Until:    kube.Drained(plan.Evict),
+	}, "the pods of node "+node+" to be evicted")
+	if err != nil {
+		cancel()
+	}
+	results := <-done
+	if err := kube.PrintPodActionResults(os.Stdout, "Evicted", results); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err := kube.DrainError(results); err != nil {
+		return err
+	}
+	fmt.Printf("node/%s drained\n", node)
+	return nil
+}
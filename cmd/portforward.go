@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newPortForwardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "port-forward (POD | TYPE/NAME | -l SELECTOR) [LOCAL:]REMOTE...",
+		Short: "Forward local ports to a pod",
+		Long: "Forward local ports to a pod given by name, or to a running pod of an owner or service (deploy/api, svc/web) or label selector, preferring ready pods. " +
+			"For a service REMOTE is a service port, by number or name, and is mapped to the pod through its targetPort. " +
+			"When the pod is deleted or the connection drops, forwarding moves to another running pod on the same local ports. " +
+			"LOCAL defaults to the remote port; ':REMOTE' picks a random local port.",
+		Example: "  kubepeek port-forward api-7d9f-abc 8080:80\n  kubepeek port-forward svc/web 8080:http 8443:443\n  kubepeek port-forward -l app=db :5432",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var target string
+			if a.flags.selector == "" {
+				if len(args) == 0 {
+					return errors.New("a target (POD, TYPE/NAME) or a selector is required")
+				}
+				target, args = args[0], args[1:]
+			}
+			if len(args) == 0 {
+				return errors.New("at least one port is required, e.g. 8080:80")
+			}
+			ports, err := kube.ParsePortPairs(args)
+			if err != nil {
+				return err
+			}
+			resolver, err := a.podResolver()
+			if err != nil {
+				return err
+			}
+			config, err := a.Provider.RESTConfig()
+			if err != nil {
+				return err
+			}
+
+			pf := kube.PortForward{
+				Resolver:  resolver,
+				Forwarder: kube.RemoteForwarder{Config: config, Client: a.Client, Addresses: a.flags.addresses},
+				Log:       os.Stderr,
+			}
+			return pf.Run(cmd.Context(), kube.PortForwardOpts{
+				Namespace: a.flags.namespace,
+				Target:    target,
+				Selector:  a.flags.selector,
+				Ports:     ports,
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&a.flags.selector, "selector", "l", "", "Forward to a pod matching this label selector")
+	cmd.Flags().StringSliceVar(&a.flags.addresses, "address", []string{"localhost"}, "Local addresses to listen on (comma-separated)")
+
+	return cmd
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "replay FILE",
+		Short:       "Replay a session recorded with --record",
+		Long:        "Play back a recording made with `get pods -w --record` or `top pods --watch --record` through the same printers, with the original timing scaled by --speed.",
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{offlineAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			source, err := kube.LoadRecording(f)
+			if err != nil {
+				return err
+			}
+			source.Speed = a.flags.speed
+			if a.flags.since != "" {
+				if source.Since, err = seekTime(a.flags.since, source.Start()); err != nil {
+					return err
+				}
+			}
+
+			if source.HasMetrics() {
+				ctrl := kube.MetricsController{Source: source, Printer: a.metricsPrinter()}
+				return ctrl.Run(cmd.Context(), kube.MetricsOpts{Watch: true})
+			}
+
+			a.flags.watch = true
+			ctrl := kube.Controller{
+				Source:         source,
+				CurrentPrinter: a.podPrinter(),
+				// Fills RECENT-RESTARTS from the recorded crashes
+				Restarts: kube.NewRestartTracker(io.Discard),
+			}
+			return ctrl.Run(cmd.Context(), kube.RunOpts{Watch: true})
+		},
+	}
+
+	cmd.Flags().Float64Var(&a.flags.speed, "speed", 1, "Playback speed multiplier; 0 plays without delays")
+	cmd.Flags().StringVar(&a.flags.since, "since", "", "Seek to a time: RFC3339 timestamp or offset from the start of the recording (e.g. 90s)")
+	cmd.Flags().BoolVar(&a.flags.diff, "diff", false, "Print field-level changes of pods instead of repainting the table")
+
+	return cmd
+}
+
+// seekTime parses --since as a timestamp or an offset from start.
+func seekTime(arg string, start time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(arg); err == nil {
+		return start.Add(d), nil
+	}
+	t, err := time.Parse(time.RFC3339, arg)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: want an RFC3339 time or a duration", arg)
+	}
+	return t, nil
+}
@@ -2,12 +2,18 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/massanaRoger/kube-peek/internal/kube"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -16,6 +22,7 @@ type App struct {
 	Provider kube.Provider
 	root     *cobra.Command
 	flags    Flags
+	config   *kube.Config
 }
 
 type Flags struct {
@@ -25,21 +32,53 @@ type Flags struct {
 	fieldSelector string
 	watch         bool
 	output        string
+	rolloutWatch  bool
+	timeout       time.Duration
+	problems      bool
+	top           int
+	restartLog    string
+	diff          bool
+	refresh       time.Duration
+	maxFPS        int
+	record        string
+	interval      time.Duration
+	speed         float64
+	since         string
+	from          []string
+	filter        string
+	regex         string
+	chunkSize     int64
+	metadataOnly  bool
+	columns       []string
+	container     string
+	stdin         bool
+	tty           bool
+	execAll       bool
+	deleteAll     bool
+	concurrency   int
+	group         bool
+	addresses     []string
+	address       string
+	yes           bool
+	dryRun        string
+	gracePeriod   int64
+	force         bool
+	olderThan     time.Duration
+	reasons       []string
+	owners        []string
+	batchSize     int
+	wait          bool
+	waitTimeout   time.Duration
+	replicas      int
+	requests      string
+	limits        string
+	image         string
+	target        string
+	copyTo        string
 }
 
 func NewApp() (*App, error) {
 	a := &App{}
-	provider, err := kube.NewProvider()
-	if err != nil {
-		return nil, err
-	}
-	client, err := provider.ClientSet()
-	if err != nil {
-		return nil, err
-	}
-
-	a.Client = client.(*kubernetes.Clientset)
-	a.Provider = provider
 
 	a.root = &cobra.Command{
 		Use:           "kubepeek",
@@ -48,10 +87,16 @@ func NewApp() (*App, error) {
 		SilenceUsage:  true, // don't print usage on errors
 		SilenceErrors: true, // let us format errors
 		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := a.loadConfig(cmd); err != nil {
+				return err
+			}
 			if a.flags.allNamespaces {
 				a.flags.namespace = ""
 			}
-			return nil
+			if cmd.Annotations[offlineAnnotation] == "true" || len(a.flags.from) > 0 {
+				return nil
+			}
+			return a.connect()
 		},
 		// If run with no subcommand, show help.
 		RunE: func(cmd *cobra.Command, _ []string) error {
@@ -64,11 +109,38 @@ func NewApp() (*App, error) {
 	topCmd := a.newTopCmd()
 	topPodsCmd := a.newTopPodsCmd()
 
+	rolloutCmd := a.newRolloutCmd()
+
 	getCmd.AddCommand(getPodsCmd)
+	getCmd.AddCommand(a.newGetWorkloadsCmd(kube.KindDeployment, "deployments", "deployment", "deploy"))
+	getCmd.AddCommand(a.newGetWorkloadsCmd(kube.KindStatefulSet, "statefulsets", "statefulset", "sts"))
+	getCmd.AddCommand(a.newGetWorkloadsCmd(kube.KindDaemonSet, "daemonsets", "daemonset", "ds"))
 	topCmd.AddCommand(topPodsCmd)
+	rolloutCmd.AddCommand(a.newRolloutStatusCmd())
+	rolloutCmd.AddCommand(a.newRolloutRestartCmd())
 
 	a.root.AddCommand(getCmd)
 	a.root.AddCommand(topCmd)
+	a.root.AddCommand(rolloutCmd)
+	a.root.AddCommand(a.newTreeCmd())
+	a.root.AddCommand(a.newWhyCmd())
+	a.root.AddCommand(a.newStatusCmd())
+	a.root.AddCommand(a.newReplayCmd())
+	a.root.AddCommand(a.newExecCmd())
+	a.root.AddCommand(a.newCpCmd())
+	a.root.AddCommand(a.newDebugCmd())
+	a.root.AddCommand(a.newDeleteCmd())
+	a.root.AddCommand(a.newEvictCmd())
+	a.root.AddCommand(a.newCleanCmd())
+	a.root.AddCommand(a.newScaleCmd())
+	a.root.AddCommand(a.newResizeCmd())
+	a.root.AddCommand(a.newCordonCmd())
+	a.root.AddCommand(a.newUncordonCmd())
+	a.root.AddCommand(a.newDrainCmd())
+	a.root.AddCommand(a.newPortForwardCmd())
+	a.root.AddCommand(a.newForwardCmd())
+	a.root.AddCommand(a.newViewCmd())
+	a.root.AddCommand(a.newConfigCmd())
 
 	a.root.PersistentFlags().StringVarP(&a.flags.namespace, "namespace", "n", "default", "The namespace scope for this CLI request")
 
@@ -83,6 +155,26 @@ func NewApp() (*App, error) {
 	return a, nil
 }
 
+// offlineAnnotation marks commands that never talk to the cluster.
+const offlineAnnotation = "kubepeek/offline"
+
+// connect sets up the clients. It is deferred until a command needs the
+// cluster so offline commands work without a kubeconfig.
+func (a *App) connect() error {
+	provider, err := kube.NewProvider()
+	if err != nil {
+		return err
+	}
+	client, err := provider.ClientSet()
+	if err != nil {
+		return err
+	}
+
+	a.Client = client.(*kubernetes.Clientset)
+	a.Provider = provider
+	return nil
+}
+
 func (a *App) newGetCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "get",
@@ -91,40 +183,171 @@ func (a *App) newGetCmd() *cobra.Command {
 }
 
 func (a *App) newGetPodsCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "pods",
+	cmd := &cobra.Command{
+		Use:   "pods [NAME|PATTERN...]",
 		Short: "List pods",
+		Long:  "List pods, optionally only the ones named. Arguments are exact names or shell globs such as 'redis-*'; --regex adds a regular expression. A pod is shown if any of them matches.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			ns := a.flags.namespace
-			var printer kube.Printer
+			// Watch-only flags given without -w; config defaults for them
+			// just wait for a watch
+			for _, name := range []string{"restart-log", "diff"} {
+				if cmd.Flags().Changed(name) && !a.flags.watch {
+					return fmt.Errorf("--%s needs --watch", name)
+				}
+			}
+			columns, err := kube.ParsePodColumns(a.flags.columns)
+			if err != nil {
+				return err
+			}
+			a.flags.columns = columns
+			printer := a.podPrinter()
 
-			switch a.flags.output {
-			case "json":
-				printer = kube.NewJsonPrinter(os.Stdout)
-			default:
+			var source kube.PodSource
+			if len(a.flags.from) > 0 {
 				if a.flags.watch {
-					printer = kube.NewLiveTablePrinter(os.Stdout)
-				} else {
-					printer = kube.NewTablePrinter(os.Stdout)
+					return fmt.Errorf("--watch: %w", kube.ErrOffline)
+				}
+				files, err := kube.NewFileSource(os.Stderr, a.flags.from...)
+				if err != nil {
+					return err
+				}
+				source = files
+			} else if a.flags.metadataOnly {
+				md, err := a.Provider.MetadataClient()
+				if err != nil {
+					return err
+				}
+				source = kube.MetadataSource{Client: md}
+			} else {
+				source = kube.ClientGoSource{Client: a.Client}
+			}
+			var recorder *kube.Recorder
+			if a.flags.record != "" {
+				f, err := os.Create(a.flags.record)
+				if err != nil {
+					return err
 				}
+				defer f.Close()
+				recorder = kube.NewRecorder(f)
+				source = kube.RecordingSource{Source: source, Recorder: recorder}
 			}
 
 			ctrl := kube.Controller{
-				Source:         kube.ClientGoSource{Client: a.Client},
+				Source:         source,
 				CurrentPrinter: printer,
 			}
 
-			return ctrl.Run(ctx, kube.RunOpts{
+			if a.flags.watch {
+				var log io.Writer
+				if a.flags.restartLog != "" {
+					f, err := os.OpenFile(a.flags.restartLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					log = f
+				}
+				ctrl.Restarts = kube.NewRestartTracker(log)
+			}
+
+			opts := kube.RunOpts{
 				Namespace: ns,
 				ListOpts: kube.ListOpts{
 					LabelSelector: a.flags.selector,
 					FieldSelector: a.flags.fieldSelector,
+					Limit:         a.flags.chunkSize,
 				},
-				Watch: a.flags.watch,
-			})
+				Watch:           a.flags.watch,
+				RefreshInterval: a.flags.refresh,
+				MaxFPS:          a.flags.maxFPS,
+			}
+			names, err := kube.ParseNameMatch(args, a.flags.regex)
+			if err != nil {
+				return err
+			}
+			opts.Names = names
+			if opts.Filter, err = a.podFilter(); err != nil {
+				return err
+			}
+
+			if err := ctrl.Run(ctx, opts); err != nil {
+				return err
+			}
+			if recorder != nil {
+				return recorder.Err()
+			}
+			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&a.flags.problems, "problems", false, "Only show pods that are not running and ready or successfully completed")
+	cmd.Flags().Int64Var(&a.flags.chunkSize, "chunk-size", 0, "List pods in pages of this size, printing each page as it arrives; the first page sizes the columns and longer cells are cut (0 lists everything at once)")
+	cmd.Flags().BoolVar(&a.flags.metadataOnly, "metadata-only", false, "Fetch only pod metadata, which is much faster on large clusters; shows NAME, NAMESPACE, OWNER, LABELS and AGE")
+	cmd.Flags().StringSliceVar(&a.flags.columns, "columns", nil, "Columns to show, e.g. NAME,STATUS,NODE (any of "+strings.Join(kube.PodColumns, ", ")+")")
+	cmd.Flags().StringVar(&a.flags.regex, "regex", "", "Only show pods whose name matches this regular expression, e.g. '^api-'")
+	cmd.Flags().StringVar(&a.flags.filter, "filter", "", `Client-side filter expression, e.g. 'restarts > 3 && status != "Running" && node =~ "worker-.*"'`)
+	cmd.Flags().DurationVar(&a.flags.refresh, "refresh-interval", 200*time.Millisecond, "In watch mode, batch the changes arriving within this window into one refresh (0 refreshes on every event)")
+	cmd.Flags().IntVar(&a.flags.maxFPS, "max-fps", 10, "In watch mode, refresh the output at most this many times per second (0 for no limit)")
+	cmd.Flags().BoolVar(&a.flags.diff, "diff", false, "In watch mode, print field-level changes of pods instead of repainting the table")
+	cmd.Flags().StringVar(&a.flags.restartLog, "restart-log", "", "In watch mode, append every container crash (time, pod, container, reason, exit code) to this file")
+	cmd.Flags().StringSliceVar(&a.flags.from, "from", nil, "Read pods from these files or directories (YAML/JSON manifests, kubectl dumps, must-gather) instead of the cluster")
+	cmd.Flags().StringVar(&a.flags.record, "record", "", "Record the initial list and every watch event with timestamps to this file, for `kubepeek replay`")
+
+	return cmd
+}
+
+// podFilter combines --problems and --filter; nil keeps every pod.
+func (a *App) podFilter() (func(v1.Pod) bool, error) {
+	var filter func(v1.Pod) bool
+	if a.flags.problems {
+		filter = kube.HasProblems
+	}
+	if a.flags.filter != "" {
+		keep, err := kube.ParsePodFilter(a.flags.filter)
+		if err != nil {
+			return nil, err
+		}
+		if problems := filter; problems != nil {
+			filter = func(p v1.Pod) bool { return problems(p) && keep(p) }
+		} else {
+			filter = keep
+		}
+	}
+	return filter, nil
+}
+
+// podPrinter picks the pod printer for the output and watch flags.
+func (a *App) podPrinter() kube.Printer {
+	if a.flags.output == "json" {
+		return kube.NewJsonPrinter(os.Stdout)
+	}
+	columns := a.flags.columns
+	if columns == nil && a.flags.metadataOnly {
+		columns = kube.MetadataPodColumns
+	}
+	if !a.flags.watch && a.flags.chunkSize > 0 {
+		stream := kube.NewStreamingTablePrinter(os.Stdout)
+		stream.Columns = columns
+		return stream
+	}
+	if !a.flags.watch {
+		table := kube.NewTablePrinter(os.Stdout)
+		table.Columns = columns
+		return table
+	}
+	if columns == nil {
+		columns = append(slices.Clone(kube.DefaultPodColumns), "RECENT-RESTARTS")
+	}
+	if a.flags.diff {
+		diff := kube.NewDiffPrinter(os.Stdout)
+		diff.Columns = columns
+		return diff
+	}
+	live := kube.NewLiveTablePrinter(os.Stdout)
+	live.Columns = columns
+	return live
 }
 
 func (a *App) newTopCmd() *cobra.Command {
@@ -135,20 +358,12 @@ func (a *App) newTopCmd() *cobra.Command {
 }
 
 func (a *App) newTopPodsCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "pods",
 		Short: "Display resource usage of pods",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			ns := a.flags.namespace
-			var printer kube.MetricsPrinterInterface
-
-			switch a.flags.output {
-			case "json":
-				printer = kube.NewMetricsJSONPrinter(os.Stdout)
-			default:
-				printer = kube.NewMetricsPrinter(os.Stdout)
-			}
 
 			client, err := a.Provider.ClientSet()
 			if err != nil {
@@ -160,19 +375,60 @@ func (a *App) newTopPodsCmd() *cobra.Command {
 				return err
 			}
 
+			var source kube.UsageSource = kube.MetricsSource{
+				Client:        client,
+				MetricsClient: metricsClient,
+			}
+			var recorder *kube.Recorder
+			if a.flags.record != "" {
+				f, err := os.Create(a.flags.record)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				recorder = kube.NewRecorder(f)
+				source = kube.RecordingUsageSource{Source: source, Recorder: recorder}
+			}
+
 			ctrl := kube.MetricsController{
-				Source: kube.MetricsSource{
-					Client:        client,
-					MetricsClient: metricsClient,
-				},
-				Printer: printer,
+				Source:  source,
+				Printer: a.metricsPrinter(),
 			}
 
-			return ctrl.Run(ctx, kube.MetricsOpts{
+			opts := kube.MetricsOpts{
 				Namespace: ns,
-			})
+				Watch:     a.flags.watch,
+				Interval:  a.flags.interval,
+			}
+			if a.flags.filter != "" {
+				if opts.Filter, err = kube.ParseMetricsFilter(a.flags.filter); err != nil {
+					return err
+				}
+			}
+
+			if err := ctrl.Run(ctx, opts); err != nil {
+				return err
+			}
+			if recorder != nil {
+				return recorder.Err()
+			}
+			return nil
 		},
 	}
+
+	cmd.Flags().BoolVarP(&a.flags.watch, "watch", "w", false, "Keep polling the usage every --interval")
+	cmd.Flags().StringVar(&a.flags.filter, "filter", "", "Client-side filter expression over name, namespace, cpu and memory, e.g. 'cpu > 500m || memory > 1Gi'")
+	cmd.Flags().DurationVar(&a.flags.interval, "interval", 15*time.Second, "In watch mode, how often to poll the metrics")
+	cmd.Flags().StringVar(&a.flags.record, "record", "", "Record every usage sample with timestamps to this file, for `kubepeek replay`")
+
+	return cmd
+}
+
+func (a *App) metricsPrinter() kube.MetricsPrinterInterface {
+	if a.flags.output == "json" {
+		return kube.NewMetricsJSONPrinter(os.Stdout)
+	}
+	return kube.NewMetricsPrinter(os.Stdout)
 }
 
 func Execute() error {
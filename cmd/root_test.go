@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWatchOnlyFlags(t *testing.T) {
+	t.Setenv("KUBEPEEK_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	for _, flag := range []string{"--restart-log=crashes.log", "--diff"} {
+		a, err := NewApp()
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.root.SetArgs([]string{"get", "pods", "--from", "pods.json", flag})
+		if err := a.root.Execute(); err == nil || !strings.Contains(err.Error(), strings.Split(flag, "=")[0]+" needs --watch") {
+			t.Errorf("get pods %s without -w: error = %v", flag, err)
+		}
+	}
+}
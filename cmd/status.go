@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Summarise cluster health",
+		Long:  "Summarise pod status reasons, recent restarts and not-ready pods per namespace, NotReady or pressured nodes, and the top CPU and memory consumers. Covers all namespaces unless --namespace is given.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ns := a.flags.namespace
+			if !cmd.Flags().Changed("namespace") {
+				ns = ""
+			}
+
+			var printer kube.StatusPrinter
+			switch a.flags.output {
+			case "json":
+				printer = kube.NewStatusJSONPrinter(os.Stdout)
+			default:
+				printer = kube.NewStatusTextPrinter(os.Stdout)
+			}
+
+			client, err := a.Provider.ClientSet()
+			if err != nil {
+				return err
+			}
+			metricsClient, err := a.Provider.MetricsClient()
+			if err != nil {
+				return err
+			}
+
+			source := kube.ClientGoSource{Client: client}
+			collector := kube.StatusCollector{
+				Pods:  source,
+				Nodes: source,
+				Metrics: &kube.MetricsSource{
+					Client:        client,
+					MetricsClient: metricsClient,
+				},
+				Top: a.flags.top,
+			}
+
+			st, err := collector.Collect(cmd.Context(), ns)
+			if err != nil {
+				return err
+			}
+			return printer.Print(st)
+		},
+	}
+
+	cmd.Flags().IntVar(&a.flags.top, "top", 5, "Number of top CPU and memory consumers to show")
+
+	return cmd
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newTreeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tree [TYPE/NAME]",
+		Short: "Show the ownership tree of pods",
+		Long:  "Walk ownerReferences up from every pod in the namespace (Pod → ReplicaSet → Deployment, Pod → Job → CronJob, ...), or down from the given workload, and print the resulting tree. Pods without a live owner are listed under (no owner).",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dyn, err := a.Provider.DynamicClient()
+			if err != nil {
+				return err
+			}
+			mapper, err := a.Provider.RESTMapper()
+			if err != nil {
+				return err
+			}
+
+			var printer kube.TreePrinter
+			switch a.flags.output {
+			case "json":
+				printer = kube.NewTreeJSONPrinter(os.Stdout)
+			default:
+				printer = kube.NewTreeTextPrinter(os.Stdout)
+			}
+
+			opts := kube.TreeOpts{
+				Namespace: a.flags.namespace,
+				ListOpts:  kube.ListOpts{LabelSelector: a.flags.selector},
+			}
+			if len(args) == 1 {
+				opts.Root = args[0]
+			}
+
+			builder := kube.TreeBuilder{
+				Pods:    kube.ClientGoSource{Client: a.Client},
+				Dynamic: dyn,
+				Mapper:  mapper,
+			}
+			roots, err := builder.Build(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return printer.Print(roots)
+		},
+	}
+
+	cmd.Flags().StringVarP(&a.flags.selector, "selector", "l", "", "Selector (label query) to filter pods on")
+
+	return cmd
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newWhyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "why",
+		Short: "Explain why a resource is not working",
+	}
+	cmd.AddCommand(a.newWhyPodCmd())
+	return cmd
+}
+
+func (a *App) newWhyPodCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pod NAME",
+		Short: "Diagnose why a pod is not running",
+		Long:  "Inspect a pod's conditions, container states, events and volume claims and print a ranked, plain-language diagnosis together with the evidence it is based on.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var printer kube.DiagnosisPrinter
+			switch a.flags.output {
+			case "json":
+				printer = kube.NewDiagnosisJSONPrinter(os.Stdout)
+			default:
+				printer = kube.NewDiagnosisTextPrinter(os.Stdout)
+			}
+
+			var source kube.EvidenceSource = kube.ClientGoSource{Client: a.Client}
+			if len(a.flags.from) > 0 {
+				files, err := kube.NewFileSource(os.Stderr, a.flags.from...)
+				if err != nil {
+					return err
+				}
+				source = files
+			}
+
+			diagnoser := kube.Diagnoser{Source: source}
+			d, err := diagnoser.Diagnose(cmd.Context(), a.flags.namespace, args[0])
+			if err != nil {
+				return err
+			}
+			return printer.Print(d)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&a.flags.from, "from", nil, "Read the pod, its events and claims from these files or directories instead of the cluster")
+
+	return cmd
+}
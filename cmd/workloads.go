@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/massanaRoger/kube-peek/internal/kube"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newGetWorkloadsCmd(kind, use string, aliases ...string) *cobra.Command {
+	return &cobra.Command{
+		Use:     use,
+		Aliases: aliases,
+		Short:   "List " + use,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			var printer kube.WorkloadPrinter
+
+			switch a.flags.output {
+			case "json":
+				printer = kube.NewWorkloadJSONPrinter(os.Stdout)
+			default:
+				printer = kube.NewWorkloadTablePrinter(os.Stdout)
+			}
+
+			ctrl := kube.WorkloadController{
+				Source:  kube.ClientGoWorkloadSource{Client: a.Client},
+				Printer: printer,
+			}
+
+			return ctrl.Run(ctx, kube.WorkloadOpts{
+				Kind:      kind,
+				Namespace: a.flags.namespace,
+				ListOpts: kube.ListOpts{
+					LabelSelector: a.flags.selector,
+					FieldSelector: a.flags.fieldSelector,
+				},
+				Watch: a.flags.watch,
+			})
+		},
+	}
+}
+
+func (a *App) newRolloutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollout",
+		Short: "Manage the rollout of a workload",
+	}
+}
+
+func (a *App) newRolloutStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status (TYPE NAME | TYPE/NAME)",
+		Short: "Watch the rollout status of a deployment, statefulset or daemonset",
+		Long:  "Watch the rollout status of a workload until it completes. Exits non-zero if the rollout stalls (ProgressDeadlineExceeded), the workload is deleted or the timeout is hit.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := kube.ParseObjectRef(args)
+			if err != nil {
+				return err
+			}
+
+			watcher := kube.RolloutWatcher{
+				Source: kube.ClientGoWorkloadSource{Client: a.Client},
+				Out:    os.Stdout,
+			}
+
+			return watcher.Run(cmd.Context(), kube.RolloutOpts{
+				Namespace: a.flags.namespace,
+				Ref:       ref,
+				Watch:     a.flags.rolloutWatch,
+				Timeout:   a.flags.timeout,
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&a.flags.rolloutWatch, "watch", "w", true, "Watch the status of the rollout until it's done")
+	cmd.Flags().DurationVar(&a.flags.timeout, "timeout", 0, "The length of time to wait before giving up (e.g. 5m). Zero means never")
+
+	return cmd
+}
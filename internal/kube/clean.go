@@ -0,0 +1,228 @@
+package kube
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CleanFieldSelector lists only pods that have finished, which are the only
+// ones clean considers.
+const CleanFieldSelector = "status.phase!=Pending,status.phase!=Running,status.phase!=Unknown"
+
+// CleanCandidate is a finished pod clean would delete. Reason is its
+// STATUS as `get pods` shows it: Evicted, OOMKilled, Completed, Error...
+type CleanCandidate struct {
+	Pod      v1.Pod
+	Reason   string
+	Finished time.Time
+}
+
+type CleanOpts struct {
+	// OlderThan keeps pods that finished more recently
+	OlderThan time.Duration
+	// Reasons, when set, limits the pods to these reasons or phases
+	// (Failed, Succeeded), compared without case
+	Reasons []string
+	// Owners, when set, limits the pods to the ones whose controller is one
+	// of these: Kind, Kind/NAME with NAME a glob, or "none" for bare pods
+	Owners []string
+	// Now defaults to time.Now
+	Now time.Time
+}
+
+func (o CleanOpts) Validate() error {
+	for _, owner := range o.Owners {
+		kind, name, _ := strings.Cut(owner, "/")
+		if kind == "" {
+			return fmt.Errorf("invalid owner %q: want KIND, KIND/NAME or none", owner)
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("invalid owner %q: %w", owner, err)
+		}
+	}
+	return nil
+}
+
+// FindCleanable picks the finished pods that match the options, oldest
+// first. Pods already being deleted are left out.
+func FindCleanable(pods []v1.Pod, opts CleanOpts) []CleanCandidate {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var out []CleanCandidate
+	for _, p := range pods {
+		if p.DeletionTimestamp != nil || (p.Status.Phase != v1.PodSucceeded && p.Status.Phase != v1.PodFailed) {
+			continue
+		}
+		c := CleanCandidate{Pod: p, Reason: statusReason(p), Finished: finishedAt(p)}
+		if opts.OlderThan > 0 && now.Sub(c.Finished) < opts.OlderThan {
+			continue
+		}
+		if len(opts.Reasons) > 0 && !slices.ContainsFunc(opts.Reasons, func(r string) bool {
+			return strings.EqualFold(r, c.Reason) || strings.EqualFold(r, string(p.Status.Phase))
+		}) {
+			continue
+		}
+		if len(opts.Owners) > 0 && !ownerMatches(p, opts.Owners) {
+			continue
+		}
+		out = append(out, c)
+	}
+	slices.SortStableFunc(out, func(a, b CleanCandidate) int { return a.Finished.Compare(b.Finished) })
+	return out
+}
+
+// finishedAt is when the last container terminated, else when the pod's
+// conditions last changed, which is when evicted pods were stopped.
+func finishedAt(p v1.Pod) time.Time {
+	var t time.Time
+	statuses := append(slices.Clone(p.Status.InitContainerStatuses), p.Status.ContainerStatuses...)
+	for _, s := range statuses {
+		if s.State.Terminated != nil && s.State.Terminated.FinishedAt.After(t) {
+			t = s.State.Terminated.FinishedAt.Time
+		}
+	}
+	if !t.IsZero() {
+		return t
+	}
+	for _, c := range p.Status.Conditions {
+		if c.LastTransitionTime.After(t) {
+			t = c.LastTransitionTime.Time
+		}
+	}
+	if !t.IsZero() {
+		return t
+	}
+	return p.CreationTimestamp.Time
+}
+
+func ownerMatches(p v1.Pod, owners []string) bool {
+	ref := controllerRef(p.OwnerReferences)
+	for _, owner := range owners {
+		if strings.EqualFold(owner, "none") {
+			if ref == nil {
+				return true
+			}
+			continue
+		}
+		if ref == nil {
+			continue
+		}
+		kind, name, hasName := strings.Cut(owner, "/")
+		if !strings.EqualFold(kind, ref.Kind) {
+			continue
+		}
+		if !hasName {
+			return true
+		}
+		if ok, _ := path.Match(name, ref.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanCount is a row of the clean summary: how many pods of a namespace
+// finished for a reason, and how long ago the oldest did.
+type CleanCount struct {
+	Namespace string
+	Reason    string
+	Count     int
+	Oldest    time.Time
+}
+
+// CountCleanable groups the candidates by namespace and reason.
+func CountCleanable(cands []CleanCandidate) []CleanCount {
+	index := map[[2]string]int{}
+	var counts []CleanCount
+	for _, c := range cands {
+		key := [2]string{c.Pod.Namespace, c.Reason}
+		i, ok := index[key]
+		if !ok {
+			i = len(counts)
+			index[key] = i
+			counts = append(counts, CleanCount{Namespace: key[0], Reason: key[1], Oldest: c.Finished})
+		}
+		counts[i].Count++
+		if c.Finished.Before(counts[i].Oldest) {
+			counts[i].Oldest = c.Finished
+		}
+	}
+	slices.SortFunc(counts, func(a, b CleanCount) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(b.Count, a.Count), cmp.Compare(a.Reason, b.Reason))
+	})
+	return counts
+}
+
+func PrintCleanCounts(w io.Writer, counts []CleanCount) error {
+	table := newTable(w)
+	table.Header([]string{"NAMESPACE", "REASON", "PODS", "OLDEST"})
+	data := make([][]string, 0, len(counts))
+	total := 0
+	for _, c := range counts {
+		data = append(data, []string{c.Namespace, c.Reason, fmt.Sprint(c.Count), calcAge(c.Oldest)})
+		total += c.Count
+	}
+	table.Bulk(data)
+	table.Render()
+	_, err := fmt.Fprintf(w, "%d pods in %d namespaces\n", total, countNamespaces(counts))
+	return err
+}
+
+func countNamespaces(counts []CleanCount) int {
+	seen := map[string]bool{}
+	for _, c := range counts {
+		seen[c.Namespace] = true
+	}
+	return len(seen)
+}
+
+// PrintCleanCandidates lists every pod, for the dry-run report.
+func PrintCleanCandidates(w io.Writer, cands []CleanCandidate) error {
+	table := newTable(w)
+	table.Header([]string{"NAMESPACE", "NAME", "REASON", "OWNER", "FINISHED"})
+	data := make([][]string, 0, len(cands))
+	for _, c := range cands {
+		data = append(data, []string{c.Pod.Namespace, c.Pod.Name, c.Reason, ownerName(c.Pod.OwnerReferences), calcAge(c.Finished) + " ago"})
+	}
+	table.Bulk(data)
+	table.Render()
+	return nil
+}
+
+// DeleteInBatches deletes the pods batch pods at a time, concurrently
+// within a batch, and calls progress after each batch. It stops starting
+// batches once ctx is done.
+func DeleteInBatches(ctx context.Context, client kubernetes.Interface, pods []v1.Pod, batch int, opts PodActionOpts, progress func(done, total int)) []PodActionResult {
+	if batch <= 0 {
+		batch = 50
+	}
+	results := make([]PodActionResult, len(pods))
+	for start := 0; start < len(pods); start += batch {
+		end := min(start+batch, len(pods))
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = DeletePods(ctx, client, pods[i:i+1], opts)[0]
+			}()
+		}
+		wg.Wait()
+		if progress != nil {
+			progress(end, len(pods))
+		}
+	}
+	return results
+}
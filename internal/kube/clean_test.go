@@ -0,0 +1,120 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func finishedPod(ns, name string, phase v1.PodPhase, reason string, ago time.Duration, owner string) v1.Pod {
+	finished := metav1.NewTime(time.Now().Add(-ago))
+	p := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, CreationTimestamp: metav1.NewTime(finished.Add(-time.Hour))},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+	if reason == "Evicted" {
+		p.Status.Reason = reason
+		p.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionFalse, LastTransitionTime: finished}}
+	} else {
+		p.Status.ContainerStatuses = []v1.ContainerStatus{{
+			State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: reason, FinishedAt: finished}},
+		}}
+	}
+	if kind, name, ok := strings.Cut(owner, "/"); ok {
+		controller := true
+		p.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+	}
+	return p
+}
+
+func cleanNames(cands []CleanCandidate) string {
+	var names []string
+	for _, c := range cands {
+		names = append(names, c.Pod.Name+"="+c.Reason)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestFindCleanable(t *testing.T) {
+	running := labeledPod("api-1", "api", true, time.Hour)
+	terminating := finishedPod("shop", "old-job", v1.PodSucceeded, "Completed", 48*time.Hour, "Job/old")
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	pods := []v1.Pod{
+		running,
+		terminating,
+		finishedPod("shop", "evicted-1", v1.PodFailed, "Evicted", 30*time.Hour, "ReplicaSet/web-7d9f"),
+		finishedPod("shop", "oom-1", v1.PodFailed, "OOMKilled", 2*time.Hour, ""),
+		finishedPod("batch", "nightly-1", v1.PodSucceeded, "Completed", 72*time.Hour, "Job/nightly-1"),
+		finishedPod("batch", "report-1", v1.PodFailed, "Error", 26*time.Hour, "Job/report-1"),
+	}
+
+	tests := []struct {
+		opts CleanOpts
+		want string
+	}{
+		{CleanOpts{}, "nightly-1=Completed evicted-1=Evicted report-1=Error oom-1=OOMKilled"},
+		{CleanOpts{OlderThan: 24 * time.Hour}, "nightly-1=Completed evicted-1=Evicted report-1=Error"},
+		{CleanOpts{Reasons: []string{"evicted", "OOMKilled"}}, "evicted-1=Evicted oom-1=OOMKilled"},
+		{CleanOpts{Reasons: []string{"Failed"}, OlderThan: 24 * time.Hour}, "evicted-1=Evicted report-1=Error"},
+		{CleanOpts{Owners: []string{"job/nightly-*"}}, "nightly-1=Completed"},
+		{CleanOpts{Owners: []string{"none", "ReplicaSet"}}, "evicted-1=Evicted oom-1=OOMKilled"},
+	}
+	for _, tt := range tests {
+		if got := cleanNames(FindCleanable(pods, tt.opts)); got != tt.want {
+			t.Errorf("FindCleanable(%+v) = %s, want %s", tt.opts, got, tt.want)
+		}
+	}
+
+	var out bytes.Buffer
+	PrintCleanCounts(&out, CountCleanable(FindCleanable(pods, CleanOpts{})))
+	for _, want := range []string{"BATCH", "Completed", "OOMKilled", "4 pods in 2 namespaces"} {
+		if !strings.Contains(strings.ToUpper(out.String()), strings.ToUpper(want)) {
+			t.Errorf("counts output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if err := (CleanOpts{Owners: []string{"/x"}}).Validate(); err == nil {
+		t.Error("Validate(/x) error = nil")
+	}
+}
+
+func TestDeleteInBatches(t *testing.T) {
+	var pods []v1.Pod
+	var objs []runtime.Object
+	for i := range 7 {
+		p := finishedPod("batch", fmt.Sprintf("job-%d", i), v1.PodSucceeded, "Completed", time.Hour, "")
+		pods = append(pods, p)
+		objs = append(objs, &p)
+	}
+	client := fake.NewSimpleClientset(objs...)
+	var deletes atomic.Int32
+	client.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deletes.Add(1)
+		return false, nil, nil
+	})
+
+	var progress []string
+	results := DeleteInBatches(context.Background(), client, pods, 3, PodActionOpts{GracePeriod: -1}, func(done, total int) {
+		progress = append(progress, fmt.Sprintf("%d/%d", done, total))
+	})
+	if strings.Join(progress, " ") != "3/7 6/7 7/7" {
+		t.Errorf("progress = %v", progress)
+	}
+	for i, r := range results {
+		if r.Name != pods[i].Name || r.Failed() {
+			t.Errorf("results[%d] = %+v", i, r)
+		}
+	}
+	if deletes.Load() != 7 {
+		t.Errorf("%d deletes, want 7", deletes.Load())
+	}
+}
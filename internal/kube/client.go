@@ -4,7 +4,13 @@ import (
 	"flag"
 	"path/filepath"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -13,11 +19,20 @@ import (
 type Provider interface {
 	ClientSet() (kubernetes.Interface, error)
 	MetricsClient() (metricsclientset.Interface, error)
+	DynamicClient() (dynamic.Interface, error)
+	RESTMapper() (meta.RESTMapper, error)
+	MetadataClient() (metadata.Interface, error)
+	// RESTConfig is for clients that stream, such as exec and port-forward
+	RESTConfig() (*rest.Config, error)
 }
 
 type provider struct {
 	client        kubernetes.Interface
 	metricsClient metricsclientset.Interface
+	dynamicClient dynamic.Interface
+	mapper        meta.RESTMapper
+	metadata      metadata.Interface
+	config        *rest.Config
 }
 
 func (f *provider) ClientSet() (kubernetes.Interface, error) {
@@ -28,6 +43,32 @@ func (f *provider) MetricsClient() (metricsclientset.Interface, error) {
 	return f.metricsClient, nil
 }
 
+func (f *provider) DynamicClient() (dynamic.Interface, error) {
+	return f.dynamicClient, nil
+}
+
+func (f *provider) RESTMapper() (meta.RESTMapper, error) {
+	return f.mapper, nil
+}
+
+func (f *provider) MetadataClient() (metadata.Interface, error) {
+	return f.metadata, nil
+}
+
+func (f *provider) RESTConfig() (*rest.Config, error) {
+	return f.config, nil
+}
+
+// ProtobufConfig returns a copy of config that talks protobuf, which is
+// smaller on the wire and faster to decode than JSON. Only built-in types
+// support it, so it is used for the typed clientset alone.
+func ProtobufConfig(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.AcceptContentTypes = "application/vnd.kubernetes.protobuf,application/json"
+	config.ContentType = "application/vnd.kubernetes.protobuf"
+	return config
+}
+
 func NewProvider() (*provider, error) {
 	var kubeconfig *string
 	if home := homedir.HomeDir(); home != "" {
@@ -42,7 +83,7 @@ func NewProvider() (*provider, error) {
 		return nil, err
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	clientset, err := kubernetes.NewForConfig(ProtobufConfig(config))
 	if err != nil {
 		return nil, err
 	}
@@ -52,9 +93,27 @@ func NewProvider() (*provider, error) {
 		return nil, err
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Discovery is cached in memory and only hit when a mapping is first needed
+	discoveryClient := memory.NewMemCacheClient(clientset.Discovery())
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient), discoveryClient, nil)
+
 	return &provider{
 		client:        clientset,
 		metricsClient: metricsClientset,
+		dynamicClient: dynamicClient,
+		mapper:        mapper,
+		metadata:      metadataClient,
+		config:        config,
 	}, nil
 }
 
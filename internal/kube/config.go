@@ -0,0 +1,285 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the user's config file:
+//
+//	defaults:
+//	  output: json
+//	  refresh-interval: 500ms
+//	views:
+//	  oncall:
+//	    resource: pods
+//	    namespaces: [shop, payments]
+//	    selector: tier=backend
+//	    filter: restarts > 3
+//	    columns: [NAME, STATUS, RESTARTS, NODE]
+//	profiles:
+//	  dev:
+//	    - target: svc/web
+//	      namespace: shop
+//	      ports: ["8080:http"]
+//	    - name: db
+//	      selector: app=postgres
+//	      ports: ["5432"]
+//
+// Defaults are flag values, by flag name, used when the flag is not given
+// on the command line.
+type Config struct {
+	Defaults map[string]string        `json:"defaults,omitempty"`
+	Views    map[string]View          `json:"views,omitempty"`
+	Profiles map[string][]ForwardSpec `json:"profiles,omitempty"`
+}
+
+// View is a saved listing, run with `kubepeek view NAME`.
+type View struct {
+	// Resource is pods (the default), deployments, statefulsets or daemonsets
+	Resource string `json:"resource,omitempty"`
+	// Namespaces to list; none means the current one and "*" all of them
+	Namespaces    []string `json:"namespaces,omitempty"`
+	Selector      string   `json:"selector,omitempty"`
+	FieldSelector string   `json:"fieldSelector,omitempty"`
+	Filter        string   `json:"filter,omitempty"`
+	Columns       []string `json:"columns,omitempty"`
+	Output        string   `json:"output,omitempty"`
+}
+
+// ForwardSpec is one port-forward of a profile, started with
+// `kubepeek forward up PROFILE`.
+type ForwardSpec struct {
+	// Name labels the forward in the status table; the target by default
+	Name      string   `json:"name,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Target    string   `json:"target,omitempty"`
+	Selector  string   `json:"selector,omitempty"`
+	Ports     []string `json:"ports"`
+}
+
+func (s ForwardSpec) Validate() error {
+	if (s.Target == "") == (s.Selector == "") {
+		return errors.New("give either a target or a selector")
+	}
+	if len(s.Ports) == 0 {
+		return errors.New("no ports")
+	}
+	pairs, err := ParsePortPairs(s.Ports)
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		_, err := strconv.Atoi(p.Remote)
+		if p.Local == "0" || (p.Local == "" && err != nil) {
+			return fmt.Errorf("port %s needs a fixed local port, e.g. 8080:%s", p.Remote, p.Remote)
+		}
+	}
+	return nil
+}
+
+func (s ForwardSpec) DisplayName() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Target != "":
+		return s.Target
+	}
+	return s.Selector
+}
+
+// ViewResources are the resources a view can list.
+var ViewResources = []string{"pods", "deployments", "statefulsets", "daemonsets"}
+
+// DefaultConfigPath is $KUBEPEEK_CONFIG, or config.yaml under
+// $XDG_CONFIG_HOME/kubepeek, falling back to ~/.config/kubepeek.
+func DefaultConfigPath() string {
+	if p := os.Getenv("KUBEPEEK_CONFIG"); p != "" {
+		return p
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(homedir.HomeDir(), ".config")
+	}
+	return filepath.Join(dir, "kubepeek", "config.yaml")
+}
+
+// LoadConfig reads the config file at path and checks its views and
+// profiles. A missing file is an empty config.
+func LoadConfig(path string) (*Config, error) {
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ReadConfig reads the config file at path without checking its settings,
+// so that a broken one can still be fixed with `config set`.
+func ReadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) Validate() error {
+	for name, v := range c.Views {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("view %q: %w", name, err)
+		}
+	}
+	for name, specs := range c.Profiles {
+		for i, s := range specs {
+			if err := s.Validate(); err != nil {
+				return fmt.Errorf("profile %q, forward %d: %w", name, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Set changes one setting by its dotted key: defaults.FLAG or
+// views.NAME.FIELD, with FIELD named as in the file. List fields take
+// comma-separated values. An empty value removes the setting, and a view
+// left with no fields is removed.
+func (c *Config) Set(key, value string) error {
+	section, rest, _ := strings.Cut(key, ".")
+	switch section {
+	case "defaults":
+		if rest == "" || strings.Contains(rest, ".") {
+			return fmt.Errorf("config: want defaults.FLAG, got %q", key)
+		}
+		if value == "" {
+			delete(c.Defaults, rest)
+			return nil
+		}
+		if c.Defaults == nil {
+			c.Defaults = map[string]string{}
+		}
+		c.Defaults[rest] = value
+		return nil
+	case "views":
+		name, field, ok := strings.Cut(rest, ".")
+		if !ok || name == "" {
+			return fmt.Errorf("config: want views.NAME.FIELD, got %q", key)
+		}
+		v := c.Views[name]
+		if err := v.set(field, value); err != nil {
+			return err
+		}
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("config: view %q: %w", name, err)
+		}
+		if v.IsZero() {
+			delete(c.Views, name)
+			return nil
+		}
+		if c.Views == nil {
+			c.Views = map[string]View{}
+		}
+		c.Views[name] = v
+		return nil
+	}
+	return fmt.Errorf("config: unknown key %q, want defaults.FLAG or views.NAME.FIELD", key)
+}
+
+func (v *View) set(field, value string) error {
+	var list []string
+	if value != "" {
+		list = strings.Split(value, ",")
+	}
+	switch field {
+	case "resource":
+		v.Resource = value
+	case "namespaces":
+		v.Namespaces = list
+	case "selector":
+		v.Selector = value
+	case "fieldSelector":
+		v.FieldSelector = value
+	case "filter":
+		v.Filter = value
+	case "columns":
+		v.Columns = list
+	case "output":
+		v.Output = value
+	default:
+		return fmt.Errorf("config: unknown view field %q (known: resource, namespaces, selector, fieldSelector, filter, columns, output)", field)
+	}
+	return nil
+}
+
+func (v View) IsZero() bool {
+	return v.Resource == "" && len(v.Namespaces) == 0 && v.Selector == "" && v.FieldSelector == "" &&
+		v.Filter == "" && len(v.Columns) == 0 && v.Output == ""
+}
+
+// Validate checks what can be checked without running the view.
+func (v View) Validate() error {
+	resource := v.ResourceOrDefault()
+	if !slices.Contains(ViewResources, resource) {
+		return fmt.Errorf("unknown resource %q (known: %s)", resource, strings.Join(ViewResources, ", "))
+	}
+	if v.Output != "" && v.Output != "table" && v.Output != "json" {
+		return fmt.Errorf("unknown output %q, want table or json", v.Output)
+	}
+	if len(v.Namespaces) > 1 && slices.Contains(v.Namespaces, "*") {
+		return errors.New(`namespace "*" means all namespaces and cannot be combined with others`)
+	}
+	if resource != "pods" {
+		// Only pods can be filtered client side, which is how several
+		// namespaces are listed
+		if len(v.Namespaces) > 1 {
+			return fmt.Errorf("%s views take one namespace or \"*\"", resource)
+		}
+		if v.Filter != "" || len(v.Columns) > 0 {
+			return errors.New("filter and columns are only supported for pods")
+		}
+		return nil
+	}
+	if v.Filter != "" {
+		if _, err := ParsePodFilter(v.Filter); err != nil {
+			return err
+		}
+	}
+	_, err := ParsePodColumns(v.Columns)
+	return err
+}
+
+func (v View) ResourceOrDefault() string {
+	if v.Resource == "" {
+		return "pods"
+	}
+	return v.Resource
+}
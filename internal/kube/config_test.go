@@ -0,0 +1,89 @@
+package kube
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConfig_SetSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubepeek", "config.yaml")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() of a missing file error = %v", err)
+	}
+
+	for _, kv := range [][2]string{
+		{"defaults.output", "json"},
+		{"defaults.refresh-interval", "500ms"},
+		{"views.oncall.namespaces", "shop,payments"},
+		{"views.oncall.filter", "restarts > 3"},
+		{"views.oncall.columns", "name,status,node"},
+		{"views.deploys.resource", "deployments"},
+		{"views.deploys.resource", ""}, // removes the only field, and so the view
+	} {
+		if err := cfg.Set(kv[0], kv[1]); err != nil {
+			t.Fatalf("Set(%q, %q) error = %v", kv[0], kv[1], err)
+		}
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Defaults["output"] != "json" || got.Defaults["refresh-interval"] != "500ms" {
+		t.Errorf("Defaults = %v", got.Defaults)
+	}
+	if _, ok := got.Views["deploys"]; ok {
+		t.Error("view deploys was not removed")
+	}
+	v := got.Views["oncall"]
+	if !slices.Equal(v.Namespaces, []string{"shop", "payments"}) || v.Filter != "restarts > 3" || v.ResourceOrDefault() != "pods" {
+		t.Errorf("Views[oncall] = %+v", v)
+	}
+}
+
+func TestConfig_SetErrors(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       string
+	}{
+		{"output", "json", "unknown key"},
+		{"views.oncall", "x", "want views.NAME.FIELD"},
+		{"views.oncall.sort", "age", "unknown view field"},
+		{"views.oncall.resource", "services", "unknown resource"},
+		{"views.oncall.columns", "NAME,CPU", `unknown column "CPU"`},
+		{"views.oncall.filter", "restart > 3", `unknown field "restart"`},
+		{"views.oncall.namespaces", "*,shop", "cannot be combined"},
+		{"views.oncall.output", "yaml", "unknown output"},
+	}
+	for _, tt := range tests {
+		err := (&Config{}).Set(tt.key, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Set(%q, %q) error = %v, want it to mention %q", tt.key, tt.value, err, tt.want)
+		}
+	}
+}
+
+func TestForwardSpec_Validate(t *testing.T) {
+	tests := []struct {
+		spec ForwardSpec
+		want string
+	}{
+		{ForwardSpec{Target: "svc/web", Ports: []string{"8080:http", "9090"}}, ""},
+		{ForwardSpec{Target: "svc/web", Selector: "app=web", Ports: []string{"80"}}, "either a target or a selector"},
+		{ForwardSpec{Selector: "app=web"}, "no ports"},
+		{ForwardSpec{Target: "svc/web", Ports: []string{":80"}}, "needs a fixed local port"},
+		{ForwardSpec{Target: "svc/web", Ports: []string{"http"}}, "needs a fixed local port"},
+	}
+	for _, tt := range tests {
+		err := tt.spec.Validate()
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("Validate(%+v) error = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
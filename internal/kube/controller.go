@@ -2,22 +2,45 @@ package kube
 
 import (
 	"context"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"syscall"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 type Controller struct {
 	Source         PodSource
 	CurrentPrinter Printer
+	// Restarts, when set, tracks container crashes during watch mode and
+	// fills the RecentRestarts column
+	Restarts *RestartTracker
 }
 
 type RunOpts struct {
 	Namespace string
 	ListOpts  ListOpts
 	Watch     bool
+	// Filter drops pods it returns false for, both from the initial list and
+	// from watch events. Nil keeps every pod.
+	Filter func(v1.Pod) bool
+	// Names limits the pods by name. Exact names alone are fetched one by
+	// one; globs and regexes are matched client side like Filter.
+	Names NameMatch
+	// RefreshInterval coalesces the watch events arriving within it into a
+	// single frame; MaxFPS caps how many frames are drawn per second. Zero
+	// disables either limit.
+	RefreshInterval time.Duration
+	MaxFPS          int
+	// Until, in watch mode, is checked against the pods after the initial
+	// list and every change; watching stops once it returns true or an
+	// error.
+	Until func([]v1.Pod) (bool, error)
 }
 
 const (
@@ -27,19 +50,67 @@ const (
 )
 
 func (c Controller) Run(ctx context.Context, opts RunOpts) error {
-	// 1) Initial LIST
-	list, err := c.Source.List(ctx, opts.Namespace, opts.ListOpts)
-	if err != nil {
+	if s, ok := c.CurrentPrinter.(Stopper); ok {
+		defer s.Stop()
+	}
+	keep := opts.Filter
+	if !opts.Names.IsZero() {
+		keep = func(p v1.Pod) bool {
+			return opts.Names.Matches(p.Name) && (opts.Filter == nil || opts.Filter(p))
+		}
+	}
+
+	// Without watch, pages go straight to a printer that can show them
+	if pp, ok := c.CurrentPrinter.(PagePrinter); ok && !opts.Watch && opts.ListOpts.Limit > 0 && !opts.Names.ExactOnly() {
+		if src, ok := c.Source.(PagedSource); ok {
+			return streamList(ctx, src, pp, opts, keep)
+		}
+	}
+
+	// 1) Initial LIST, or GET of the pods named
+	var (
+		list     *v1.PodList
+		err      error
+		notFound error
+	)
+	if opts.Names.ExactOnly() {
+		list, notFound = getByName(ctx, c.Source, opts.Namespace, opts.ListOpts, opts.Names.Exact)
+		if list == nil {
+			return notFound
+		}
+	} else if list, err = c.Source.List(ctx, opts.Namespace, opts.ListOpts); err != nil {
 		return err
 	}
 
-	// Snapshot -> rows -> print
-	rows := ToRows(list.Items)
+	// Restarts are tracked for every pod, not only the ones shown: a crash
+	// may be what makes a pod match the filter.
+	var seen map[string]v1.Pod
+	if c.Restarts != nil {
+		seen = make(map[string]v1.Pod, len(list.Items))
+		for _, p := range list.Items {
+			seen[p.Namespace+"/"+p.Name] = p
+		}
+	}
+	list.Items = filterPods(list.Items, keep)
+
+	// Snapshot -> sorted rows -> print. The set is kept as the local store
+	// (keyed by ns/name) in watch mode.
+	store := newPodRowSet(list.Items)
+	rows := c.withRestarts(store.Rows())
 	if err := c.CurrentPrinter.Print(rows); err != nil {
 		return err
 	}
 	if !opts.Watch {
-		return nil
+		return notFound
+	}
+	settled := func() (bool, error) {
+		if opts.Until == nil {
+			return false, nil
+		}
+		return opts.Until(slices.Collect(maps.Values(store.pods)))
+	}
+	if done, err := settled(); err != nil || done {
+		return err
 	}
 
 	// 2) WATCH from the same ResourceVersion
@@ -50,43 +121,137 @@ func (c Controller) Run(ctx context.Context, opts RunOpts) error {
 	}
 	defer w.Stop()
 
-	// Keep a local store keyed by ns/name
-	store := map[string]v1.Pod{}
-	for _, p := range list.Items {
-		store[p.Namespace+"/"+p.Name] = p
-	}
-
 	// Cancel on Ctrl+C too
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	// Changes are coalesced: the first one schedules a frame, later ones
+	// before it is drawn only update the store.
+	var (
+		flush     <-chan time.Time
+		lastFrame time.Time
+	)
+	render := func() error {
+		flush = nil
+		lastFrame = time.Now()
+		return c.CurrentPrinter.Refresh(c.withRestarts(store.Rows()))
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-flush:
+			if err := render(); err != nil {
+				return err
+			}
 		case ev, ok := <-w.ResultChan():
 			if !ok {
+				// stream closed; draw what is still pending
+				if flush != nil {
+					return render()
+				}
 				return nil
-			} // stream closed
-
-			switch obj := ev.Object.(type) {
-			case *v1.Pod:
-				key := obj.Namespace + "/" + obj.Name
-				switch ev.Type {
-				case EventTypeAdded, EventTypeModified:
-					store[key] = *obj
-				case EventTypeDeleted:
-					delete(store, key)
+			}
+
+			obj, isPod := ev.Object.(*v1.Pod)
+			if !isPod {
+				continue
+			}
+			changed, err := c.apply(store, seen, ev.Type, obj, keep)
+			if err != nil {
+				return err
+			}
+			if changed {
+				done, err := settled()
+				if err != nil {
+					return err
 				}
-				// Re-render snapshot
-				snap := make([]v1.Pod, 0, len(store))
-				for _, p := range store {
-					snap = append(snap, p)
+				if done {
+					return render()
 				}
-				if err := c.CurrentPrinter.Refresh(ToRows(snap)); err != nil {
+			}
+			if !changed || flush != nil {
+				continue
+			}
+			delay := opts.RefreshInterval
+			if opts.MaxFPS > 0 {
+				delay = max(delay, time.Until(lastFrame.Add(time.Second/time.Duration(opts.MaxFPS))))
+			}
+			if delay <= 0 {
+				if err := render(); err != nil {
 					return err
 				}
+				continue
 			}
+			flush = time.After(delay)
+		}
+	}
+}
+
+// streamList prints the pods page by page. The server lists pods in
+// namespace/name order, so sorting each page keeps the whole table sorted.
+func streamList(ctx context.Context, src PagedSource, out PagePrinter, opts RunOpts, keep func(v1.Pod) bool) error {
+	_, err := src.ListPages(ctx, opts.Namespace, opts.ListOpts, func(pods []v1.Pod) error {
+		pods = filterPods(pods, keep)
+		if len(pods) == 0 {
+			return nil
+		}
+		return out.PrintPage(newPodRowSet(pods).Rows())
+	})
+	// Close the table even after an error so the output stays well formed
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// apply updates the store with a watch event and reports whether the
+// visible rows may have changed. seen holds the last version of every pod,
+// filtered out or not, for restart tracking.
+func (c Controller) apply(store *podRowSet, seen map[string]v1.Pod, evType watch.EventType, obj *v1.Pod, filter func(v1.Pod) bool) (bool, error) {
+	key := obj.Namespace + "/" + obj.Name
+	switch evType {
+	case EventTypeAdded, EventTypeModified:
+		if c.Restarts != nil {
+			if old, ok := seen[key]; ok {
+				if _, err := c.Restarts.Observe(&old, obj); err != nil {
+					return false, err
+				}
+			}
+			seen[key] = *obj
+		}
+		if filter != nil && !filter(*obj) {
+			// It may have matched before this change
+			return store.Delete(obj.Namespace, obj.Name), nil
+		}
+		store.Upsert(*obj)
+		return true, nil
+	case EventTypeDeleted:
+		delete(seen, key)
+		return store.Delete(obj.Namespace, obj.Name), nil
+	}
+	return false, nil
+}
+
+func (c Controller) withRestarts(rows []PodRow) []PodRow {
+	if c.Restarts != nil {
+		for i := range rows {
+			rows[i].RecentRestarts = strconv.Itoa(c.Restarts.Recent(rows[i].Namespace + "/" + rows[i].Name))
+		}
+	}
+	return rows
+}
+
+func filterPods(pods []v1.Pod, keep func(v1.Pod) bool) []v1.Pod {
+	if keep == nil {
+		return pods
+	}
+	out := pods[:0]
+	for _, p := range pods {
+		if keep(p) {
+			out = append(out, p)
 		}
 	}
+	return out
 }
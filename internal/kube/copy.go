@@ -0,0 +1,613 @@
+package kube
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CopySpec is a cp argument: a local path, or TARGET:PATH in a pod where
+// TARGET is POD or TYPE/NAME.
+type CopySpec struct {
+	Target string
+	Path   string
+}
+
+func (s CopySpec) Remote() bool { return s.Target != "" }
+
+func (s CopySpec) String() string {
+	if s.Remote() {
+		return s.Target + ":" + s.Path
+	}
+	return s.Path
+}
+
+// ParseCopySpec tells local paths from TARGET:PATH. Paths starting with
+// / or . are local, and so is C:\ and the like.
+func ParseCopySpec(arg string) (CopySpec, error) {
+	target, p, ok := strings.Cut(arg, ":")
+	if !ok || strings.HasPrefix(arg, "/") || strings.HasPrefix(arg, ".") || len(target) == 1 {
+		return CopySpec{Path: arg}, nil
+	}
+	if target == "" {
+		return CopySpec{}, fmt.Errorf("invalid path %q: missing the pod before ':'", arg)
+	}
+	if p == "" {
+		return CopySpec{}, fmt.Errorf("invalid path %q: missing the path after ':'", arg)
+	}
+	return CopySpec{Target: target, Path: p}, nil
+}
+
+// CopyPod is the container files are copied to or from.
+type CopyPod struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
+// Copier copies files between the local machine and a container by
+// streaming tar over exec. Without tar in the image, single files are
+// copied with cat and tee instead.
+type Copier struct {
+	Exec Executor
+	// Progress, when set, shows the bytes copied so far while copying on a
+	// terminal, and a summary line at the end
+	Progress io.Writer
+	// Log receives warnings, such as skipped entries or the cat fallback
+	Log io.Writer
+}
+
+// Upload copies the local file or directory src to dst in the container.
+// When dst is a directory the copy goes inside it, like cp.
+func (c Copier) Upload(ctx context.Context, pod CopyPod, src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	total, err := localSize(src)
+	if err != nil {
+		return err
+	}
+	dst = path.Clean(dst)
+	dir, name := path.Dir(dst), path.Base(dst)
+	if c.remoteIsDir(ctx, pod, dst) {
+		dir, name = dst, filepath.Base(src)
+	}
+
+	progress := c.startProgress(total)
+	defer progress.stop()
+
+	pr, pw := io.Pipe()
+	tarErr := make(chan error, 1)
+	go func() {
+		err := writeTar(pw, src, name, progress)
+		pw.CloseWithError(err)
+		tarErr <- err
+	}()
+	var stderr bytes.Buffer
+	err = c.Exec.Exec(ctx, ExecOpts{
+		Namespace: pod.Namespace,
+		Pod:       pod.Pod,
+		Container: pod.Container,
+		Command:   []string{"tar", "-xf", "-", "-C", dir},
+		Stdin:     pr,
+		Stdout:    io.Discard,
+		Stderr:    &stderr,
+	})
+	// Unblock the tar writer if the remote side stopped reading early
+	pr.CloseWithError(io.ErrClosedPipe)
+	if werr := <-tarErr; werr != nil && !errors.Is(werr, io.ErrClosedPipe) && err == nil {
+		return werr
+	}
+	if commandMissing(err, stderr.String()) {
+		if info.IsDir() {
+			return noTar(pod, false)
+		}
+		fmt.Fprintf(c.log(), "tar not found in container, copying %s with tee instead\n", src)
+		progress.reset()
+		return c.uploadFile(ctx, pod, src, path.Join(dir, name), info.Mode(), progress)
+	}
+	if err != nil {
+		return execError(err, &stderr)
+	}
+	progress.done(pod.Pod + ":" + dst)
+	return nil
+}
+
+func (c Copier) uploadFile(ctx context.Context, pod CopyPod, src, dst string, mode fs.FileMode, progress *copyProgress) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var stderr bytes.Buffer
+	err = c.Exec.Exec(ctx, ExecOpts{
+		Namespace: pod.Namespace,
+		Pod:       pod.Pod,
+		Container: pod.Container,
+		Command:   []string{"tee", dst},
+		Stdin:     io.TeeReader(f, progress),
+		Stdout:    io.Discard,
+		Stderr:    &stderr,
+	})
+	if commandMissing(err, stderr.String()) {
+		return noTar(pod, true)
+	}
+	if err != nil {
+		return execError(err, &stderr)
+	}
+	// Best effort: tee creates the file with the default mode
+	c.Exec.Exec(ctx, ExecOpts{
+		Namespace: pod.Namespace,
+		Pod:       pod.Pod,
+		Container: pod.Container,
+		Command:   []string{"chmod", fmt.Sprintf("%o", mode.Perm()), dst},
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+	})
+	progress.done(pod.Pod + ":" + dst)
+	return nil
+}
+
+// remoteIsDir reports whether p is a directory in the container. Images
+// without test are taken to have no such directory.
+func (c Copier) remoteIsDir(ctx context.Context, pod CopyPod, p string) bool {
+	err := c.Exec.Exec(ctx, ExecOpts{
+		Namespace: pod.Namespace,
+		Pod:       pod.Pod,
+		Container: pod.Container,
+		Command:   []string{"test", "-d", p},
+		Stdout:    io.Discard,
+		Stderr:    io.Discard,
+	})
+	return err == nil
+}
+
+// Download copies the file or directory src in the container to the local
+// path dst. When dst is an existing directory the copy goes inside it.
+func (c Copier) Download(ctx context.Context, pod CopyPod, src, dst string) error {
+	src = path.Clean(src)
+	if path.Base(src) == "/" || path.Base(src) == "." {
+		return fmt.Errorf("cannot copy %s: name a file or directory", src)
+	}
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		dst = filepath.Join(dst, path.Base(src))
+	}
+
+	progress := c.startProgress(0)
+	defer progress.stop()
+
+	pr, pw := io.Pipe()
+	var stderr bytes.Buffer
+	execErr := make(chan error, 1)
+	go func() {
+		err := c.Exec.Exec(ctx, ExecOpts{
+			Namespace: pod.Namespace,
+			Pod:       pod.Pod,
+			Container: pod.Container,
+			Command:   []string{"tar", "-cf", "-", "-C", path.Dir(src), path.Base(src)},
+			Stdout:    pw,
+			Stderr:    &stderr,
+		})
+		pw.Close()
+		execErr <- err
+	}()
+	extractErr := c.extractTar(io.TeeReader(pr, progress), path.Base(src), dst)
+	// Drain what is left so the remote tar can finish
+	io.Copy(io.Discard, pr)
+	err := <-execErr
+
+	if commandMissing(err, stderr.String()) {
+		fmt.Fprintf(c.log(), "tar not found in container, copying %s with cat instead\n", src)
+		progress.reset()
+		return c.downloadFile(ctx, pod, src, dst, progress)
+	}
+	if err != nil {
+		return execError(err, &stderr)
+	}
+	if extractErr != nil {
+		return extractErr
+	}
+	progress.done(dst)
+	return nil
+}
+
+func (c Copier) downloadFile(ctx context.Context, pod CopyPod, src, dst string, progress *copyProgress) error {
+	tmp := dst + ".kubepeek-cp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	err = c.Exec.Exec(ctx, ExecOpts{
+		Namespace: pod.Namespace,
+		Pod:       pod.Pod,
+		Container: pod.Container,
+		Command:   []string{"cat", src},
+		Stdout:    io.MultiWriter(f, progress),
+		Stderr:    &stderr,
+	})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		if strings.Contains(stderr.String(), "Is a directory") {
+			return noTar(pod, false)
+		}
+		if commandMissing(err, stderr.String()) {
+			return noTar(pod, true)
+		}
+		return execError(err, &stderr)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	progress.done(dst)
+	return nil
+}
+
+// noTar explains that directories cannot be copied without tar, or with
+// tools set, that nothing can be.
+func noTar(pod CopyPod, tools bool) error {
+	where := "pod " + pod.Pod
+	if pod.Container != "" {
+		where = fmt.Sprintf("container %q of pod %s", pod.Container, pod.Pod)
+	}
+	if tools {
+		return fmt.Errorf("cannot copy files with %s: the image has neither tar nor cat and tee", where)
+	}
+	return fmt.Errorf("cannot copy directories with %s: tar is not installed in the image; copy single files, or add tar to the image", where)
+}
+
+func (c Copier) log() io.Writer {
+	if c.Log == nil {
+		return io.Discard
+	}
+	return c.Log
+}
+
+// commandMissing reports whether exec failed because the command is not in
+// the image; runtimes report it as exit code 126 or 127, or in the error.
+func commandMissing(err error, stderr string) bool {
+	if err == nil {
+		return false
+	}
+	code, _ := exitCode(err)
+	if code == 126 || code == 127 {
+		return true
+	}
+	msg := err.Error() + stderr
+	return strings.Contains(msg, "executable file not found") || strings.Contains(msg, "no such file or directory: unknown")
+}
+
+// execError adds what the remote command printed to its error.
+func execError(err error, stderr *bytes.Buffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	return err
+}
+
+// writeTar archives the local src under name, keeping modes and times but
+// not ownership, which means nothing in the container.
+func writeTar(w io.Writer, src, name string, progress io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(name, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(io.MultiWriter(tw, progress), f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func localSize(src string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// extractTar writes the entries under name to dst. Entries outside name,
+// with .. in them, under a symlink or that are symlinks leading out of dst
+// are skipped.
+func (c Copier) extractTar(r io.Reader, name, dst string) error {
+	tr := tar.NewReader(r)
+	type dirMode struct {
+		path string
+		hdr  *tar.Header
+	}
+	var dirs []dirMode
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rel, ok := entryPath(hdr.Name, name)
+		if !ok {
+			fmt.Fprintf(c.log(), "skipping %s: outside the copied path\n", hdr.Name)
+			continue
+		}
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		// Symlinks in the archive are only lexically checked, and chains of
+		// them can still lead out: never write through one
+		if throughSymlink(dst, rel) {
+			fmt.Fprintf(c.log(), "skipping %s: it is under a symlink\n", hdr.Name)
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if isSymlink(target) {
+				fmt.Fprintf(c.log(), "skipping %s: it is a symlink\n", hdr.Name)
+				continue
+			}
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			// Modes are set at the end so read-only directories can be
+			// filled first
+			dirs = append(dirs, dirMode{target, hdr})
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if isSymlink(target) {
+				os.Remove(target)
+			}
+			mode := hdr.FileInfo().Mode().Perm()
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode|0o200)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+			// The umask may have masked bits away
+			if err := os.Chmod(target, mode); err != nil {
+				return err
+			}
+			os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+		case tar.TypeSymlink:
+			link := filepath.FromSlash(hdr.Linkname)
+			resolved := filepath.Join(filepath.Dir(target), link)
+			if filepath.IsAbs(link) || !withinDir(dst, resolved) {
+				fmt.Fprintf(c.log(), "skipping symlink %s -> %s: it points outside the copied path\n", hdr.Name, hdr.Linkname)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+			if !resolvesWithin(dst, target) {
+				os.Remove(target)
+				fmt.Fprintf(c.log(), "skipping symlink %s -> %s: it points outside the copied path\n", hdr.Name, hdr.Linkname)
+			}
+		default:
+			fmt.Fprintf(c.log(), "skipping %s: unsupported file type\n", hdr.Name)
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		if isSymlink(d.path) {
+			continue
+		}
+		os.Chmod(d.path, d.hdr.FileInfo().Mode().Perm())
+		os.Chtimes(d.path, d.hdr.ModTime, d.hdr.ModTime)
+	}
+	return nil
+}
+
+// entryPath returns the entry's path relative to the copied name, "." for
+// the name itself.
+func entryPath(entry, name string) (string, bool) {
+	entry = strings.TrimSuffix(entry, "/")
+	if entry == name {
+		return ".", true
+	}
+	rel, ok := strings.CutPrefix(entry, name+"/")
+	if !ok || path.IsAbs(rel) {
+		return "", false
+	}
+	for _, part := range strings.Split(rel, "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	return rel, true
+}
+
+// throughSymlink reports whether any existing parent of rel below dst is
+// a symlink.
+func throughSymlink(dst, rel string) bool {
+	p := dst
+	parts := strings.Split(rel, "/")
+	for _, part := range parts[:len(parts)-1] {
+		p = filepath.Join(p, part)
+		info, err := os.Lstat(p)
+		if err != nil {
+			return false
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isSymlink(p string) bool {
+	info, err := os.Lstat(p)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// resolvesWithin reports whether the symlink at p, followed through any
+// other symlinks, stays inside dst. Dangling links pass, as nothing is
+// written through them.
+func resolvesWithin(dst, p string) bool {
+	root, err := filepath.EvalSymlinks(dst)
+	if err != nil {
+		return false
+	}
+	real, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		// Dangling: nothing is ever written through it, and its lexical
+		// target was checked
+		return true
+	}
+	return withinDir(root, real)
+}
+
+func withinDir(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// copyProgress counts copied bytes. On a terminal it redraws a progress
+// line every 200ms; elsewhere it only prints the summary.
+type copyProgress struct {
+	out     io.Writer
+	tty     bool
+	total   int64
+	n       atomic.Int64
+	start   time.Time
+	stopped chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+func (c Copier) startProgress(total int64) *copyProgress {
+	p := &copyProgress{out: c.Progress, total: total, start: time.Now(), stopped: make(chan struct{})}
+	if p.out == nil {
+		return p
+	}
+	if _, tty := terminalFd(p.out); tty {
+		p.tty = true
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			ticker := time.NewTicker(200 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					fmt.Fprintf(p.out, "\r\x1b[K%s", p.line())
+				case <-p.stopped:
+					return
+				}
+			}
+		}()
+	}
+	return p
+}
+
+func (p *copyProgress) Write(b []byte) (int, error) {
+	p.n.Add(int64(len(b)))
+	return len(b), nil
+}
+
+func (p *copyProgress) line() string {
+	n := p.n.Load()
+	if p.total > 0 {
+		return fmt.Sprintf("Copying %s / %s (%d%%)", formatBytes(n), formatBytes(p.total), min(n*100/p.total, 100))
+	}
+	return "Copying " + formatBytes(n)
+}
+
+// reset starts counting again, for a retry with another command.
+func (p *copyProgress) reset() { p.n.Store(0) }
+
+func (p *copyProgress) stop() {
+	p.once.Do(func() {
+		close(p.stopped)
+		p.wg.Wait()
+		if p.tty {
+			fmt.Fprint(p.out, "\r\x1b[K")
+		}
+	})
+}
+
+func (p *copyProgress) done(dst string) {
+	p.stop()
+	if p.out != nil {
+		fmt.Fprintf(p.out, "Copied %s to %s in %s\n", formatBytes(p.n.Load()), dst, time.Since(p.start).Round(time.Millisecond))
+	}
+}
+
+func formatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return formatMemory(n)
+}
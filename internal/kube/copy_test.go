@@ -0,0 +1,248 @@
+package kube
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// containerFS runs the commands Copier uses against a local directory
+// standing in for the container's filesystem.
+func containerFS(root string, hasTar bool) execFunc {
+	in := func(p string) string { return filepath.Join(root, filepath.FromSlash(p)) }
+	missing := utilexec.CodeExitError{Err: errors.New(`exec: "tar": executable file not found in $PATH`), Code: 127}
+	failed := utilexec.CodeExitError{Err: errors.New("command terminated with exit code 1"), Code: 1}
+	return func(ctx context.Context, opts ExecOpts) error {
+		args := opts.Command
+		switch args[0] {
+		case "test":
+			if info, err := os.Stat(in(args[2])); err != nil || !info.IsDir() {
+				return failed
+			}
+			return nil
+		case "tar":
+			if !hasTar {
+				return missing
+			}
+			if args[1] == "-cf" {
+				return writeTar(opts.Stdout, in(args[4]+"/"+args[5]), args[5], io.Discard)
+			}
+			tr := tar.NewReader(opts.Stdin)
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				p := in(args[4] + "/" + hdr.Name)
+				if hdr.Typeflag == tar.TypeDir {
+					os.MkdirAll(p, 0o755)
+					continue
+				}
+				data, _ := io.ReadAll(tr)
+				if err := os.WriteFile(p, data, hdr.FileInfo().Mode()); err != nil {
+					return err
+				}
+				os.Chmod(p, hdr.FileInfo().Mode())
+			}
+		case "cat":
+			data, err := os.ReadFile(in(args[1]))
+			if err != nil {
+				io.WriteString(opts.Stderr, "cat: "+args[1]+": Is a directory")
+				return failed
+			}
+			opts.Stdout.Write(data)
+			return nil
+		case "tee":
+			data, _ := io.ReadAll(opts.Stdin)
+			return os.WriteFile(in(args[1]), data, 0o644)
+		case "chmod":
+			mode, _ := strconv.ParseUint(args[1], 8, 32)
+			return os.Chmod(in(args[2]), os.FileMode(mode))
+		}
+		return missing
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]os.FileMode) {
+	t.Helper()
+	for name, mode := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("data of "+name), mode); err != nil {
+			t.Fatal(err)
+		}
+		os.Chmod(p, mode)
+	}
+}
+
+func checkFile(t *testing.T, p, content string, mode os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(p)
+	if string(data) != content || info.Mode().Perm() != mode {
+		t.Errorf("%s = %q, %v; want %q, %v", p, data, info.Mode().Perm(), content, mode)
+	}
+}
+
+func TestParseCopySpec(t *testing.T) {
+	tests := map[string]CopySpec{
+		"api-1:/tmp/x":        {Target: "api-1", Path: "/tmp/x"},
+		"deploy/api:/etc/app": {Target: "deploy/api", Path: "/etc/app"},
+		"./a:b":               {Path: "./a:b"},
+		"/tmp/x":              {Path: "/tmp/x"},
+		`C:\data`:             {Path: `C:\data`},
+	}
+	for arg, want := range tests {
+		if got, err := ParseCopySpec(arg); err != nil || got != want {
+			t.Errorf("ParseCopySpec(%q) = %+v, %v; want %+v", arg, got, err, want)
+		}
+	}
+	for _, bad := range []string{":/tmp", "api-1:"} {
+		if _, err := ParseCopySpec(bad); err == nil {
+			t.Errorf("ParseCopySpec(%q) error = nil", bad)
+		}
+	}
+}
+
+func TestCopier_Directory(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	writeFiles(t, filepath.Join(local, "conf"), map[string]os.FileMode{"app.yaml": 0o640, "bin/run.sh": 0o755})
+	os.MkdirAll(filepath.Join(remote, "etc"), 0o755)
+	var progress bytes.Buffer
+	c := Copier{Exec: containerFS(remote, true), Progress: &progress}
+	pod := CopyPod{Namespace: "default", Pod: "api-1"}
+
+	// Into an existing directory
+	if err := c.Upload(context.Background(), pod, filepath.Join(local, "conf"), "/etc"); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(remote, "etc/conf/bin/run.sh"), "data of bin/run.sh", 0o755)
+	checkFile(t, filepath.Join(remote, "etc/conf/app.yaml"), "data of app.yaml", 0o640)
+	if !strings.HasPrefix(progress.String(), "Copied 34B to api-1:/etc") {
+		t.Errorf("progress = %q", progress.String())
+	}
+
+	// And back under a new name
+	back := filepath.Join(local, "back")
+	if err := c.Download(context.Background(), pod, "/etc/conf/", back); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(back, "bin/run.sh"), "data of bin/run.sh", 0o755)
+	checkFile(t, filepath.Join(back, "app.yaml"), "data of app.yaml", 0o640)
+}
+
+func TestCopier_WithoutTar(t *testing.T) {
+	local, remote := t.TempDir(), t.TempDir()
+	writeFiles(t, local, map[string]os.FileMode{"run.sh": 0o750, "dir/a": 0o644})
+	writeFiles(t, remote, map[string]os.FileMode{"var/log/app.log": 0o600})
+	var log bytes.Buffer
+	c := Copier{Exec: containerFS(remote, false), Log: &log}
+	pod := CopyPod{Pod: "distroless-1", Container: "app"}
+
+	if err := c.Upload(context.Background(), pod, filepath.Join(local, "run.sh"), "/run.sh"); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(remote, "run.sh"), "data of run.sh", 0o750)
+
+	if err := c.Download(context.Background(), pod, "/var/log/app.log", local); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(local, "app.log"), "data of var/log/app.log", 0o644)
+	if !strings.Contains(log.String(), "tar not found in container, copying /var/log/app.log with cat instead") {
+		t.Errorf("log = %q", log.String())
+	}
+
+	want := `cannot copy directories with container "app" of pod distroless-1: tar is not installed in the image`
+	if err := c.Upload(context.Background(), pod, filepath.Join(local, "dir"), "/dir"); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Upload(dir) error = %v", err)
+	}
+	if err := c.Download(context.Background(), pod, "/var/log", filepath.Join(local, "logs")); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Download(dir) error = %v", err)
+	}
+}
+
+func TestCopier_SkipsEscapingEntries(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []tar.Header{
+		{Name: "conf/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "conf/ok", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "conf/../../evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "other", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "conf/passwd", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		{Name: "conf/up", Typeflag: tar.TypeSymlink, Linkname: "../.."},
+		{Name: "conf/self", Typeflag: tar.TypeSymlink, Linkname: "ok"},
+	}
+	for _, hdr := range entries {
+		tw.WriteHeader(&hdr)
+	}
+	tw.Close()
+
+	dst := filepath.Join(t.TempDir(), "conf")
+	var log bytes.Buffer
+	if err := (Copier{Log: &log}).extractTar(&buf, "conf", dst); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	filepath.WalkDir(filepath.Dir(dst), func(p string, d os.DirEntry, err error) error {
+		rel, _ := filepath.Rel(filepath.Dir(dst), p)
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	})
+	if strings.Join(got, " ") != ". conf conf/ok conf/self" {
+		t.Errorf("extracted %v", got)
+	}
+	if strings.Count(log.String(), "skipping") != 4 {
+		t.Errorf("log:\n%s", log.String())
+	}
+}
+
+func TestCopier_SkipsWritesThroughSymlinks(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	// Each link looks harmless on its own, but conf/s/y is the parent of dst
+	entries := []tar.Header{
+		{Name: "conf/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "conf/s", Typeflag: tar.TypeSymlink, Linkname: "."},
+		{Name: "conf/s/y", Typeflag: tar.TypeSymlink, Linkname: ".."},
+		{Name: "conf/s/y/evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "conf/a", Typeflag: tar.TypeSymlink, Linkname: "s/s/.."},
+	}
+	for _, hdr := range entries {
+		tw.WriteHeader(&hdr)
+	}
+	tw.Close()
+
+	parent := t.TempDir()
+	dst := filepath.Join(parent, "conf")
+	var log bytes.Buffer
+	if err := (Copier{Log: &log}).extractTar(&buf, "conf", dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(filepath.Join(parent, "evil")); err == nil {
+		t.Error("evil was written outside the destination")
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "a")); err == nil {
+		t.Error("conf/a, which resolves outside the destination, was created")
+	}
+	if strings.Count(log.String(), "skipping") != 3 {
+		t.Errorf("log:\n%s", log.String())
+	}
+}
@@ -0,0 +1,210 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// DebugImages are the presets --image accepts besides full image names.
+var DebugImages = map[string]string{
+	"busybox":  "busybox:1.36",
+	"netshoot": "nicolaka/netshoot:latest",
+}
+
+// DebugImage maps a preset to its image; anything else is an image name.
+func DebugImage(image string) string {
+	if img, ok := DebugImages[image]; ok {
+		return img
+	}
+	return image
+}
+
+type DebugOpts struct {
+	Image string
+	// Target is the container whose process namespace an ephemeral
+	// container joins, or the container a copy changes
+	Target string
+	// Command runs instead of the image's entrypoint; a shell when empty
+	Command []string
+	Stdin   bool
+	TTY     bool
+}
+
+func (o DebugOpts) command() []string {
+	if len(o.Command) > 0 {
+		return o.Command
+	}
+	return []string{"sh"}
+}
+
+// AddEphemeralContainer adds a debug container to the running pod through
+// the ephemeralcontainers subresource and returns its name.
+func AddEphemeralContainer(ctx context.Context, client kubernetes.Interface, pod *v1.Pod, opts DebugOpts) (string, error) {
+	name := uniqueContainerName(pod, "debugger")
+	ec := v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    DebugImage(opts.Image),
+			Command:                  opts.command(),
+			ImagePullPolicy:          v1.PullIfNotPresent,
+			Stdin:                    opts.Stdin,
+			TTY:                      opts.TTY,
+			TerminationMessagePolicy: v1.TerminationMessageReadFile,
+		},
+		TargetContainerName: opts.Target,
+	}
+	updated := pod.DeepCopy()
+	updated.Spec.EphemeralContainers = append(updated.Spec.EphemeralContainers, ec)
+	_, err := client.CoreV1().Pods(pod.Namespace).UpdateEphemeralContainers(ctx, pod.Name, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("add debug container to pod %s: %w", pod.Name, err)
+	}
+	return name, nil
+}
+
+func uniqueContainerName(pod *v1.Pod, prefix string) string {
+	for {
+		name := prefix + "-" + utilrand.String(5)
+		taken := slices.ContainsFunc(pod.Spec.Containers, func(c v1.Container) bool { return c.Name == name }) ||
+			slices.ContainsFunc(pod.Spec.EphemeralContainers, func(c v1.EphemeralContainer) bool { return c.Name == name })
+		if !taken {
+			return name
+		}
+	}
+}
+
+// DebugCopy returns a copy of the pod, named name, for debugging a
+// crashlooping container: the target container runs the debug command
+// instead of its own, without probes to kill it, with the image replaced
+// when one is given. The copy drops labels and owners so that no
+// controller or service picks it up, and shares its process namespace.
+func DebugCopy(pod *v1.Pod, name string, opts DebugOpts) (*v1.Pod, error) {
+	cp := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   pod.Namespace,
+			Annotations: pod.Annotations,
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+	// Let the scheduler place it; the node may be why the pod is failing
+	cp.Spec.NodeName = ""
+	cp.Spec.EphemeralContainers = nil
+	share := true
+	cp.Spec.ShareProcessNamespace = &share
+	cp.Spec.RestartPolicy = v1.RestartPolicyNever
+
+	i := slices.IndexFunc(cp.Spec.Containers, func(c v1.Container) bool { return c.Name == opts.Target })
+	if i < 0 {
+		return nil, fmt.Errorf("container %q not found in pod %s", opts.Target, pod.Name)
+	}
+	c := &cp.Spec.Containers[i]
+	c.Command, c.Args = opts.command(), nil
+	if opts.Image != "" {
+		c.Image = DebugImage(opts.Image)
+	}
+	c.LivenessProbe, c.ReadinessProbe, c.StartupProbe = nil, nil, nil
+	c.Stdin, c.TTY = opts.Stdin, opts.TTY
+	return cp, nil
+}
+
+// WaitForContainer waits until the container of the pod, ephemeral or
+// not, has started. A container that fails to start or exits with an
+// error is an error that says why.
+func WaitForContainer(ctx context.Context, client kubernetes.Interface, ns, pod, container string) error {
+	w, err := client.CoreV1().Pods(ns).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", pod).String(),
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for container %s to start: %w", container, ctx.Err())
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch closed before container %s started", container)
+			}
+			if ev.Type == watch.Deleted {
+				return fmt.Errorf("pod %s was deleted", pod)
+			}
+			p, isPod := ev.Object.(*v1.Pod)
+			if !isPod {
+				continue
+			}
+			running, err := containerStarted(p, container)
+			if err != nil || running {
+				return err
+			}
+		}
+	}
+}
+
+// waitingFailures are the waiting reasons a container does not recover
+// from by itself.
+var waitingFailures = []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerError", "CreateContainerConfigError", "RunContainerError"}
+
+func containerStarted(p *v1.Pod, container string) (bool, error) {
+	statuses := append(slices.Clone(p.Status.ContainerStatuses), p.Status.EphemeralContainerStatuses...)
+	for _, s := range statuses {
+		if s.Name != container {
+			continue
+		}
+		switch {
+		case s.State.Running != nil:
+			return true, nil
+		case s.State.Terminated != nil && s.State.Terminated.ExitCode == 0:
+			// A short command can finish before the watch sees it run
+			return true, nil
+		case s.State.Terminated != nil:
+			return false, fmt.Errorf("container %s exited: %s", container, terminatedReason(s.State.Terminated))
+		case s.State.Waiting != nil && slices.Contains(waitingFailures, s.State.Waiting.Reason):
+			return false, fmt.Errorf("container %s cannot start: %s: %s", container, s.State.Waiting.Reason, s.State.Waiting.Message)
+		}
+	}
+	if p.Status.Phase == v1.PodFailed || p.Status.Phase == v1.PodSucceeded {
+		return false, fmt.Errorf("pod %s has completed (%s)", p.Name, statusReason(*p))
+	}
+	return false, nil
+}
+
+func terminatedReason(t *v1.ContainerStateTerminated) string {
+	reason := t.Reason
+	if reason == "" {
+		reason = "Terminated"
+	}
+	return fmt.Sprintf("%s, exit code %d", reason, t.ExitCode)
+}
+
+// Attach connects to the main process of a running container.
+func (e RemoteExecutor) Attach(ctx context.Context, opts ExecOpts) error {
+	req := e.Client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(opts.Namespace).
+		Name(opts.Pod).
+		SubResource("attach").
+		VersionedParams(&v1.PodAttachOptions{
+			Container: opts.Container,
+			Stdin:     opts.Stdin != nil,
+			Stdout:    opts.Stdout != nil,
+			Stderr:    opts.Stderr != nil && !opts.TTY,
+			TTY:       opts.TTY,
+		}, scheme.ParameterCodec)
+
+	exec, err := streamExecutor(e.Config, req.URL())
+	if err != nil {
+		return err
+	}
+	return exec.StreamWithContext(ctx, streamOptions(opts))
+}
@@ -0,0 +1,132 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAddEphemeralContainer(t *testing.T) {
+	pod := execPod(nil, "app", "sidecar")
+	client := fake.NewClientset(pod)
+	var got *v1.Pod
+	client.PrependReactor("update", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		u := action.(k8stesting.UpdateAction)
+		if u.GetSubresource() != "ephemeralcontainers" {
+			t.Errorf("subresource = %q, want ephemeralcontainers", u.GetSubresource())
+		}
+		got = u.GetObject().(*v1.Pod)
+		return true, got, nil
+	})
+
+	name, err := AddEphemeralContainer(context.Background(), client, pod, DebugOpts{Image: "netshoot", Target: "sidecar", Stdin: true, TTY: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(name, "debugger-") {
+		t.Errorf("name = %q", name)
+	}
+	if got == nil || len(got.Spec.EphemeralContainers) != 1 {
+		t.Fatalf("ephemeral containers = %+v", got)
+	}
+	ec := got.Spec.EphemeralContainers[0]
+	if ec.Name != name || ec.Image != "nicolaka/netshoot:latest" || ec.TargetContainerName != "sidecar" ||
+		strings.Join(ec.Command, " ") != "sh" || !ec.Stdin || !ec.TTY {
+		t.Errorf("ephemeral container = %+v", ec)
+	}
+	if len(pod.Spec.EphemeralContainers) != 0 {
+		t.Error("the pod passed in was changed")
+	}
+}
+
+func TestDebugCopy(t *testing.T) {
+	pod := execPod(nil, "app", "sidecar")
+	pod.Labels = map[string]string{"app": "api"}
+	pod.Spec.NodeName = "node-1"
+	pod.Spec.Containers[0].Image = "api:1.2"
+	pod.Spec.Containers[0].Args = []string{"--serve"}
+	pod.Spec.Containers[0].LivenessProbe = &v1.Probe{}
+
+	cp, err := DebugCopy(pod, "api-debug", DebugOpts{Target: "app", Command: []string{"sleep", "1d"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cp.Spec.Containers[0]
+	if cp.Name != "api-debug" || cp.Labels != nil || cp.Spec.NodeName != "" || cp.Spec.ShareProcessNamespace == nil || !*cp.Spec.ShareProcessNamespace {
+		t.Errorf("copy = %+v", cp.ObjectMeta)
+	}
+	if strings.Join(c.Command, " ") != "sleep 1d" || c.Args != nil || c.Image != "api:1.2" || c.LivenessProbe != nil {
+		t.Errorf("debugged container = %+v", c)
+	}
+	if pod.Spec.Containers[0].LivenessProbe == nil || pod.Spec.NodeName != "node-1" {
+		t.Error("the pod passed in was changed")
+	}
+
+	cp, err = DebugCopy(pod, "api-debug", DebugOpts{Target: "sidecar", Image: "busybox"})
+	if err != nil || cp.Spec.Containers[1].Image != "busybox:1.36" || cp.Spec.Containers[0].Image != "api:1.2" {
+		t.Errorf("copy with image = %+v, %v", cp.Spec.Containers, err)
+	}
+	if _, err := DebugCopy(pod, "api-debug", DebugOpts{Target: "db"}); err == nil {
+		t.Error("DebugCopy(unknown container) error = nil")
+	}
+}
+
+func TestContainerStarted(t *testing.T) {
+	status := func(name string, state v1.ContainerState) *v1.Pod {
+		p := execPod(nil, "app")
+		p.Status.EphemeralContainerStatuses = []v1.ContainerStatus{{Name: name, State: state}}
+		return p
+	}
+	tests := []struct {
+		name    string
+		pod     *v1.Pod
+		started bool
+		err     string
+	}{
+		{"running", status("debugger-x", v1.ContainerState{Running: &v1.ContainerStateRunning{}}), true, ""},
+		{"creating", status("debugger-x", v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}), false, ""},
+		{"not reported yet", execPod(nil, "app"), false, ""},
+		{"bad image", status("debugger-x", v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "not found"}}), false, "ErrImagePull: not found"},
+		{"finished", status("debugger-x", v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}}), true, ""},
+		{"failed", status("debugger-x", v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 127}}), false, "Error, exit code 127"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started, err := containerStarted(tt.pod, "debugger-x")
+			if started != tt.started {
+				t.Errorf("started = %v, want %v", started, tt.started)
+			}
+			if (tt.err == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWaitForContainer(t *testing.T) {
+	pod := execPod(nil, "app")
+	client := fake.NewClientset()
+	w := watch.NewFake()
+	client.PrependWatchReactor("pods", k8stesting.DefaultWatchReactor(w, nil))
+
+	go func() {
+		pending := pod.DeepCopy()
+		pending.Status.EphemeralContainerStatuses = []v1.ContainerStatus{{Name: "debugger-x", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}}}
+		w.Modify(pending)
+		running := pod.DeepCopy()
+		running.Status.EphemeralContainerStatuses = []v1.ContainerStatus{{Name: "debugger-x", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}}
+		w.Modify(running)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForContainer(ctx, client, "default", pod.Name, "debugger-x"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,420 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// EvidenceSource provides everything the diagnostics look at besides the pod
+// status itself.
+type EvidenceSource interface {
+	GetPod(ctx context.Context, ns, name string) (*v1.Pod, error)
+	PodEvents(ctx context.Context, ns, name string) ([]v1.Event, error)
+	GetPVC(ctx context.Context, ns, name string) (*v1.PersistentVolumeClaim, error)
+}
+
+func (s ClientGoSource) GetPod(ctx context.Context, ns, name string) (*v1.Pod, error) {
+	return s.Client.CoreV1().Pods(ns).Get(ctx, name, metav1.GetOptions{})
+}
+
+func (s ClientGoSource) PodEvents(ctx context.Context, ns, name string) ([]v1.Event, error) {
+	list, err := s.Client.CoreV1().Events(ns).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{
+			"involvedObject.kind": KindPod,
+			"involvedObject.name": name,
+		}.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s ClientGoSource) GetPVC(ctx context.Context, ns, name string) (*v1.PersistentVolumeClaim, error) {
+	return s.Client.CoreV1().PersistentVolumeClaims(ns).Get(ctx, name, metav1.GetOptions{})
+}
+
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+	SeverityInfo   = "info"
+)
+
+// Finding is a single explanation for why a pod isn't running, together
+// with the evidence it was derived from. Score ranks findings.
+type Finding struct {
+	Score    int
+	Severity string
+	Reason   string
+	Summary  string
+	Evidence []string
+}
+
+type Diagnosis struct {
+	Name      string
+	Namespace string
+	Status    string
+	Findings  []Finding
+}
+
+type Diagnoser struct {
+	Source EvidenceSource
+}
+
+func (d Diagnoser) Diagnose(ctx context.Context, ns, name string) (Diagnosis, error) {
+	pod, err := d.Source.GetPod(ctx, ns, name)
+	if err != nil {
+		return Diagnosis{}, err
+	}
+	events, err := d.Source.PodEvents(ctx, ns, name)
+	if err != nil {
+		return Diagnosis{}, err
+	}
+
+	pvcs := map[string]*v1.PersistentVolumeClaim{}
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		claim := vol.PersistentVolumeClaim.ClaimName
+		pvc, err := d.Source.GetPVC(ctx, ns, claim)
+		switch {
+		case apierrors.IsNotFound(err):
+			pvcs[claim] = nil
+		case err != nil:
+			return Diagnosis{}, err
+		default:
+			pvcs[claim] = pvc
+		}
+	}
+
+	return Diagnosis{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Status:    statusReason(*pod),
+		Findings:  DiagnosePod(*pod, events, pvcs),
+	}, nil
+}
+
+// DiagnosePod inspects a pod and related objects and returns findings ranked
+// from most to least likely cause. A nil entry in pvcs means the claim does
+// not exist.
+func DiagnosePod(pod v1.Pod, events []v1.Event, pvcs map[string]*v1.PersistentVolumeClaim) []Finding {
+	var findings []Finding
+	add := func(score int, reason, summary string, evidence ...string) {
+		findings = append(findings, Finding{
+			Score:    score,
+			Severity: severity(score),
+			Reason:   reason,
+			Summary:  summary,
+			Evidence: evidence,
+		})
+	}
+
+	if pod.Status.Reason == "Evicted" {
+		add(95, "Evicted", "The kubelet evicted this pod, usually because the node ran low on memory or disk. It will not be restarted; its owner creates a replacement.",
+			"status.reason=Evicted", "status.message="+pod.Status.Message)
+	}
+
+	diagnoseScheduling(pod, events, add)
+	diagnosePVCs(pod, pvcs, add)
+
+	for _, c := range pod.Status.InitContainerStatuses {
+		if c.State.Terminated != nil && c.State.Terminated.ExitCode != 0 {
+			add(75, "InitContainerFailed",
+				fmt.Sprintf("Init container %q failed with exit code %d, so the main containers have not started.", c.Name, c.State.Terminated.ExitCode),
+				fmt.Sprintf("initContainerStatuses[%s].state.terminated: exitCode=%d reason=%s", c.Name, c.State.Terminated.ExitCode, c.State.Terminated.Reason))
+		}
+		diagnoseContainer(pod, c, true, add)
+	}
+	for _, c := range pod.Status.ContainerStatuses {
+		diagnoseContainer(pod, c, false, add)
+	}
+
+	diagnoseProbes(events, add)
+
+	for _, reason := range []string{"FailedMount", "FailedAttachVolume"} {
+		if ev := latestEvent(events, reason); ev != nil {
+			add(85, reason, "A volume cannot be attached or mounted, so the containers cannot start.", fmt.Sprintf("event %s: %s", reason, ev.Message))
+		}
+	}
+
+	if pod.DeletionTimestamp != nil {
+		grace := int64(30)
+		if pod.DeletionGracePeriodSeconds != nil {
+			grace = *pod.DeletionGracePeriodSeconds
+		}
+		if time.Since(pod.DeletionTimestamp.Time) > time.Duration(grace)*time.Second {
+			evidence := []string{"metadata.deletionTimestamp=" + pod.DeletionTimestamp.UTC().Format(time.RFC3339)}
+			if len(pod.Finalizers) > 0 {
+				evidence = append(evidence, "metadata.finalizers="+strings.Join(pod.Finalizers, ","))
+			}
+			add(50, "StuckTerminating", "The pod was deleted but is still terminating past its grace period. Check finalizers and whether the node is reachable.", evidence...)
+		}
+	}
+
+	if len(findings) == 0 {
+		switch {
+		case pod.Status.Phase == v1.PodSucceeded:
+			add(10, "Completed", "All containers exited successfully; this pod has finished and is not meant to keep running.", "status.phase=Succeeded")
+		case pod.Status.Phase == v1.PodRunning && podReady(pod):
+			add(0, "Healthy", "The pod is running and ready. No problems found.", "status.phase=Running", "conditions[Ready]=True")
+		case pod.Status.Phase == v1.PodRunning:
+			add(40, "NotReady", "The pod is running but not ready; one of its containers is not passing its readiness check yet.", "status.phase=Running", "conditions[Ready]!=True")
+		default:
+			evidence := []string{"status.phase=" + string(pod.Status.Phase)}
+			for _, ev := range events {
+				if ev.Type == v1.EventTypeWarning {
+					evidence = append(evidence, fmt.Sprintf("event %s: %s", ev.Reason, ev.Message))
+				}
+			}
+			add(30, statusReason(pod), "No specific cause was identified; see the warning events for clues.", evidence...)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Score > findings[j].Score })
+	return findings
+}
+
+func diagnoseScheduling(pod v1.Pod, events []v1.Event, add func(int, string, string, ...string)) {
+	var cond *v1.PodCondition
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == v1.PodScheduled {
+			cond = &pod.Status.Conditions[i]
+		}
+	}
+	if cond == nil || cond.Status != v1.ConditionFalse {
+		return
+	}
+
+	evidence := []string{fmt.Sprintf("conditions[PodScheduled]=False reason=%s", cond.Reason)}
+	msg := cond.Message
+	if ev := latestEvent(events, "FailedScheduling"); ev != nil {
+		msg = ev.Message
+		evidence = append(evidence, "event FailedScheduling: "+ev.Message)
+	} else if cond.Message != "" {
+		evidence = append(evidence, "condition message: "+cond.Message)
+	}
+
+	var causes []string
+	lower := strings.ToLower(msg)
+	if strings.Contains(lower, "insufficient") {
+		causes = append(causes, "no node has enough free "+insufficientResources(msg)+" for the pod's requests")
+	}
+	if strings.Contains(lower, "taint") {
+		causes = append(causes, "nodes have taints the pod does not tolerate")
+	}
+	if strings.Contains(lower, "affinity") || strings.Contains(lower, "selector") {
+		causes = append(causes, "no node matches the pod's node selector or affinity rules")
+	}
+	if strings.Contains(lower, "persistentvolumeclaim") {
+		causes = append(causes, "a PersistentVolumeClaim it uses is not bound")
+	}
+
+	summary := "The scheduler cannot place this pod on any node."
+	if len(causes) > 0 {
+		summary = "The scheduler cannot place this pod: " + strings.Join(causes, "; ") + "."
+	}
+	add(90, "FailedScheduling", summary, evidence...)
+}
+
+// insufficientResources extracts the resource names from scheduler messages
+// such as "0/3 nodes are available: 3 Insufficient cpu."
+func insufficientResources(msg string) string {
+	var res []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(msg, "Insufficient ")[1:] {
+		f := strings.Fields(part)
+		if len(f) == 0 {
+			continue
+		}
+		name := strings.TrimRight(f[0], ".,")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			res = append(res, name)
+		}
+	}
+	if len(res) == 0 {
+		return "resources"
+	}
+	return strings.Join(res, " and ")
+}
+
+func diagnosePVCs(pod v1.Pod, pvcs map[string]*v1.PersistentVolumeClaim, add func(int, string, string, ...string)) {
+	names := make([]string, 0, len(pvcs))
+	for name := range pvcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pvc := pvcs[name]
+		switch {
+		case pvc == nil:
+			add(92, "PVCNotFound", fmt.Sprintf("The pod mounts PersistentVolumeClaim %q, which does not exist.", name),
+				fmt.Sprintf("spec.volumes[].persistentVolumeClaim.claimName=%s", name), "GET persistentvolumeclaims/"+name+": not found")
+		case pvc.Status.Phase != v1.ClaimBound:
+			sc := "<default>"
+			if pvc.Spec.StorageClassName != nil {
+				sc = *pvc.Spec.StorageClassName
+			}
+			add(88, "PVCNotBound", fmt.Sprintf("PersistentVolumeClaim %q is %s, so the volume cannot be attached. Check the storage class %q and its provisioner.", name, pvc.Status.Phase, sc),
+				fmt.Sprintf("persistentvolumeclaims/%s status.phase=%s", name, pvc.Status.Phase))
+		}
+	}
+}
+
+func diagnoseContainer(pod v1.Pod, c v1.ContainerStatus, init bool, add func(int, string, string, ...string)) {
+	kind := "Container"
+	if init {
+		kind = "Init container"
+	}
+	where := fmt.Sprintf("containerStatuses[%s]", c.Name)
+	if init {
+		where = "init" + strings.ToUpper(where[:1]) + where[1:]
+	}
+
+	if w := c.State.Waiting; w != nil {
+		state := fmt.Sprintf("%s.state.waiting: reason=%s message=%s", where, w.Reason, w.Message)
+		switch w.Reason {
+		case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+			add(85, w.Reason,
+				fmt.Sprintf("%s %q cannot pull image %q. Check the image name and tag, that it exists in the registry, and that imagePullSecrets grant access.", kind, c.Name, c.Image),
+				state)
+		case "CreateContainerConfigError", "CreateContainerError":
+			add(85, w.Reason,
+				fmt.Sprintf("%s %q cannot be created: %s. This is usually a missing ConfigMap, Secret or key it references.", kind, c.Name, w.Message),
+				state)
+		case "CrashLoopBackOff":
+			summary := fmt.Sprintf("%s %q keeps crashing and Kubernetes is backing off before restarting it (restarted %d times).", kind, c.Name, c.RestartCount)
+			evidence := []string{state}
+			if t := c.LastTerminationState.Terminated; t != nil {
+				summary += fmt.Sprintf(" Its last run exited with code %d (%s); check its logs with --previous.", t.ExitCode, t.Reason)
+				evidence = append(evidence, fmt.Sprintf("%s.lastState.terminated: exitCode=%d reason=%s finishedAt=%s", where, t.ExitCode, t.Reason, t.FinishedAt.UTC().Format(time.RFC3339)))
+			}
+			add(80, w.Reason, summary, evidence...)
+		case "ContainerCreating", "PodInitializing":
+			// Normal transient states; scheduling and volume findings cover the stuck cases.
+		default:
+			if w.Reason != "" {
+				add(60, w.Reason, fmt.Sprintf("%s %q is waiting: %s %s", kind, c.Name, w.Reason, w.Message), state)
+			}
+		}
+	}
+
+	for _, t := range []*v1.ContainerStateTerminated{c.State.Terminated, c.LastTerminationState.Terminated} {
+		if t != nil && t.Reason == "OOMKilled" {
+			evidence := []string{fmt.Sprintf("%s terminated: reason=OOMKilled exitCode=%d", where, t.ExitCode)}
+			if limit := memoryLimit(pod, c.Name); limit != "" {
+				evidence = append(evidence, "resources.limits.memory="+limit)
+			}
+			add(88, "OOMKilled",
+				fmt.Sprintf("%s %q was killed for exceeding its memory limit. Raise the limit or reduce the application's memory use.", kind, c.Name),
+				evidence...)
+			break
+		}
+	}
+}
+
+func diagnoseProbes(events []v1.Event, add func(int, string, string, ...string)) {
+	seen := map[string]bool{}
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		if ev.Reason != "Unhealthy" {
+			continue
+		}
+		var probe string
+		var score int
+		switch {
+		case strings.HasPrefix(ev.Message, "Liveness probe failed"):
+			probe, score = "liveness", 70
+		case strings.HasPrefix(ev.Message, "Readiness probe failed"):
+			probe, score = "readiness", 55
+		case strings.HasPrefix(ev.Message, "Startup probe failed"):
+			probe, score = "startup", 65
+		default:
+			continue
+		}
+		if seen[probe] {
+			continue
+		}
+		seen[probe] = true
+		summary := fmt.Sprintf("The %s probe is failing", probe)
+		if probe == "readiness" {
+			summary += ", so the pod receives no traffic from Services."
+		} else {
+			summary += ", so the kubelet restarts the container."
+		}
+		add(score, "ProbeFailed", summary, fmt.Sprintf("event Unhealthy (x%d): %s", max(ev.Count, 1), ev.Message))
+	}
+}
+
+func latestEvent(events []v1.Event, reason string) *v1.Event {
+	var latest *v1.Event
+	for i := range events {
+		ev := &events[i]
+		if ev.Reason != reason {
+			continue
+		}
+		if latest == nil || eventTime(*ev).After(eventTime(*latest)) {
+			latest = ev
+		}
+	}
+	return latest
+}
+
+func eventTime(ev v1.Event) time.Time {
+	if !ev.LastTimestamp.IsZero() {
+		return ev.LastTimestamp.Time
+	}
+	if !ev.EventTime.IsZero() {
+		return ev.EventTime.Time
+	}
+	return ev.FirstTimestamp.Time
+}
+
+func memoryLimit(pod v1.Pod, container string) string {
+	for _, cs := range [][]v1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for _, c := range cs {
+			if c.Name == container {
+				if q, ok := c.Resources.Limits[v1.ResourceMemory]; ok {
+					return q.String()
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func severity(score int) string {
+	switch {
+	case score >= 80:
+		return SeverityHigh
+	case score >= 50:
+		return SeverityMedium
+	case score > 10:
+		return SeverityLow
+	default:
+		return SeverityInfo
+	}
+}
+
+// HasProblems reports whether a pod needs attention: anything that is not a
+// cleanly running, ready pod or a successfully completed one.
+func HasProblems(p v1.Pod) bool {
+	switch statusReason(p) {
+	case "Completed":
+		return false
+	case "Running":
+		return !podReady(p)
+	}
+	return p.Status.Phase != v1.PodSucceeded
+}
@@ -0,0 +1,228 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStatusReason(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name string
+		pod  v1.Pod
+		want string
+	}{
+		{
+			name: "running",
+			pod: v1.Pod{Status: v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{
+				{Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+			}}},
+			want: "Running",
+		},
+		{
+			name: "crash loop",
+			pod: v1.Pod{Status: v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+			}}},
+			want: "CrashLoopBackOff",
+		},
+		{
+			name: "init container waiting",
+			pod: v1.Pod{
+				Spec: v1.PodSpec{InitContainers: []v1.Container{{Name: "a"}, {Name: "b"}}},
+				Status: v1.PodStatus{Phase: v1.PodPending, InitContainerStatuses: []v1.ContainerStatus{
+					{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}},
+					{State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+				}},
+			},
+			want: "Init:1/2",
+		},
+		{
+			name: "evicted",
+			pod:  v1.Pod{Status: v1.PodStatus{Phase: v1.PodFailed, Reason: "Evicted"}},
+			want: "Evicted",
+		},
+		{
+			name: "oom killed",
+			pod: v1.Pod{Status: v1.PodStatus{Phase: v1.PodFailed, ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}}},
+			}}},
+			want: "OOMKilled",
+		},
+		{
+			name: "terminating",
+			pod:  v1.Pod{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}, Status: v1.PodStatus{Phase: v1.PodRunning}},
+			want: "Terminating",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusReason(tt.pod); got != tt.want {
+				t.Errorf("statusReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnosePod(t *testing.T) {
+	t.Run("unschedulable", func(t *testing.T) {
+		pod := v1.Pod{Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{{
+				Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: "Unschedulable",
+			}},
+		}}
+		events := []v1.Event{{
+			Reason:  "FailedScheduling",
+			Message: "0/3 nodes are available: 1 node(s) had untolerated taint {node-role: infra}, 2 Insufficient memory.",
+		}}
+
+		findings := DiagnosePod(pod, events, nil)
+		if len(findings) == 0 || findings[0].Reason != "FailedScheduling" {
+			t.Fatalf("DiagnosePod() = %+v, want FailedScheduling first", findings)
+		}
+		for _, want := range []string{"memory", "taints"} {
+			if !strings.Contains(findings[0].Summary, want) {
+				t.Errorf("summary %q should mention %q", findings[0].Summary, want)
+			}
+		}
+	})
+
+	t.Run("oom killed ranks above crash loop", func(t *testing.T) {
+		pod := v1.Pod{
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Name:      "app",
+				Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")}},
+			}}},
+			Status: v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{{
+				Name:                 "app",
+				RestartCount:         4,
+				State:                v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+			}}},
+		}
+
+		findings := DiagnosePod(pod, nil, nil)
+		if len(findings) != 2 {
+			t.Fatalf("DiagnosePod() returned %d findings, want 2", len(findings))
+		}
+		if findings[0].Reason != "OOMKilled" || findings[1].Reason != "CrashLoopBackOff" {
+			t.Errorf("DiagnosePod() order = %s, %s", findings[0].Reason, findings[1].Reason)
+		}
+		if !strings.Contains(strings.Join(findings[0].Evidence, "\n"), "resources.limits.memory=128Mi") {
+			t.Errorf("OOMKilled evidence should include the memory limit: %v", findings[0].Evidence)
+		}
+	})
+
+	t.Run("image pull and unbound pvc", func(t *testing.T) {
+		pod := v1.Pod{Status: v1.PodStatus{Phase: v1.PodPending, ContainerStatuses: []v1.ContainerStatus{{
+			Name:  "app",
+			Image: "registry.example.com/app:nope",
+			State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+		}}}}
+		pvcs := map[string]*v1.PersistentVolumeClaim{
+			"data":    {Status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending}},
+			"missing": nil,
+		}
+
+		findings := DiagnosePod(pod, nil, pvcs)
+		var reasons []string
+		for _, f := range findings {
+			reasons = append(reasons, f.Reason)
+		}
+		if got := strings.Join(reasons, ","); got != "PVCNotFound,PVCNotBound,ImagePullBackOff" {
+			t.Errorf("DiagnosePod() reasons = %s", got)
+		}
+	})
+
+	t.Run("failing probes", func(t *testing.T) {
+		pod := v1.Pod{Status: v1.PodStatus{Phase: v1.PodRunning}}
+		events := []v1.Event{
+			{Reason: "Unhealthy", Message: "Readiness probe failed: HTTP probe failed with statuscode: 503", Count: 12},
+			{Reason: "Unhealthy", Message: "Liveness probe failed: connection refused", Count: 3},
+		}
+
+		findings := DiagnosePod(pod, events, nil)
+		if len(findings) != 2 || !strings.Contains(findings[0].Summary, "liveness") {
+			t.Errorf("DiagnosePod() = %+v, want liveness then readiness", findings)
+		}
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		pod := v1.Pod{Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+		}}
+
+		findings := DiagnosePod(pod, nil, nil)
+		if len(findings) != 1 || findings[0].Severity != SeverityInfo {
+			t.Errorf("DiagnosePod() = %+v, want a single info finding", findings)
+		}
+	})
+}
+
+func TestDiagnoser_Diagnose(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "default"},
+			Spec: v1.PodSpec{Volumes: []v1.Volume{{
+				Name:         "data",
+				VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data-db-0"}},
+			}}},
+			Status: v1.PodStatus{Phase: v1.PodPending},
+		},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "db-0.1", Namespace: "default"},
+			InvolvedObject: v1.ObjectReference{Kind: KindPod, Name: "db-0"},
+			Reason:         "FailedMount",
+			Message:        "Unable to attach or mount volumes",
+			LastTimestamp:  metav1.Time{Time: time.Now()},
+		},
+	)
+
+	d, err := Diagnoser{Source: ClientGoSource{Client: client}}.Diagnose(context.Background(), "default", "db-0")
+	if err != nil {
+		t.Fatalf("Diagnose() unexpected error: %v", err)
+	}
+	if d.Status != "Pending" || len(d.Findings) != 2 {
+		t.Fatalf("Diagnose() = %+v", d)
+	}
+	if d.Findings[0].Reason != "PVCNotFound" || d.Findings[1].Reason != "FailedMount" {
+		t.Errorf("Diagnose() reasons = %s, %s", d.Findings[0].Reason, d.Findings[1].Reason)
+	}
+}
+
+func TestController_RunFilter(t *testing.T) {
+	healthy := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ok", Namespace: "default"},
+		Status: v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{
+			{Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+		}},
+	}
+	broken := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "default"},
+		Status: v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{
+			{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+		}},
+	}
+
+	printer := &mockPrinter{}
+	ctrl := Controller{
+		Source:         &mockPodSource{listResult: &v1.PodList{Items: []v1.Pod{healthy, broken}}},
+		CurrentPrinter: printer,
+	}
+	if err := ctrl.Run(context.Background(), RunOpts{Namespace: "default", Filter: HasProblems}); err != nil {
+		t.Fatalf("Controller.Run() unexpected error: %v", err)
+	}
+	if len(printer.lastRows) != 1 || printer.lastRows[0].Name != "broken" {
+		t.Errorf("Controller.Run() rows = %+v, want only the broken pod", printer.lastRows)
+	}
+}
@@ -0,0 +1,177 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DrainRetryInterval is how long drain waits before evicting pods a
+// PodDisruptionBudget blocked again.
+const DrainRetryInterval = 5 * time.Second
+
+// NodePodsSelector is the field selector for the pods bound to the node.
+func NodePodsSelector(node string) string {
+	return fields.OneTermEqualSelector("spec.nodeName", node).String()
+}
+
+// Cordon marks the node unschedulable, or schedulable again with
+// unschedulable false. It returns false when the node already was.
+func Cordon(ctx context.Context, client kubernetes.Interface, node string, unschedulable, dryRun bool) (bool, error) {
+	n, err := client.CoreV1().Nodes().Get(ctx, node, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if n.Spec.Unschedulable == unschedulable {
+		return false, nil
+	}
+	patch, err := json.Marshal(map[string]any{"spec": map[string]any{"unschedulable": unschedulable}})
+	if err != nil {
+		return false, err
+	}
+	opts := metav1.PatchOptions{}
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	if _, err := client.CoreV1().Nodes().Patch(ctx, node, types.StrategicMergePatchType, patch, opts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DrainSkip is a pod of the node drain leaves alone, and why.
+type DrainSkip struct {
+	Pod    v1.Pod
+	Reason string
+}
+
+type DrainPlan struct {
+	Evict []v1.Pod
+	Skip  []DrainSkip
+}
+
+// PlanDrain splits the pods of a node into the ones to evict and the ones
+// eviction would not move: DaemonSet pods, which the DaemonSet recreates
+// on the node anyway, and mirror pods, which belong to the kubelet.
+func PlanDrain(pods []v1.Pod) DrainPlan {
+	var plan DrainPlan
+	for _, p := range pods {
+		switch {
+		case p.Annotations[v1.MirrorPodAnnotationKey] != "":
+			plan.Skip = append(plan.Skip, DrainSkip{Pod: p, Reason: "mirror pod"})
+		case controllerKind(p) == KindDaemonSet:
+			plan.Skip = append(plan.Skip, DrainSkip{Pod: p, Reason: "DaemonSet pod"})
+		default:
+			plan.Evict = append(plan.Evict, p)
+		}
+	}
+	return plan
+}
+
+func controllerKind(p v1.Pod) string {
+	if ref := controllerRef(p.OwnerReferences); ref != nil {
+		return ref.Kind
+	}
+	return ""
+}
+
+// PrintDrainPlan lists every pod of the node with what drain does to it.
+// Pods without a controller are flagged: once evicted they do not come
+// back anywhere.
+func PrintDrainPlan(w io.Writer, plan DrainPlan) error {
+	table := newTable(w)
+	table.Header([]string{"NAMESPACE", "NAME", "STATUS", "OWNER", "ACTION"})
+	data := make([][]string, 0, len(plan.Evict)+len(plan.Skip))
+	for _, p := range plan.Evict {
+		action := "evict"
+		if controllerRef(p.OwnerReferences) == nil {
+			action = "evict (unmanaged, will not be recreated)"
+		}
+		data = append(data, []string{p.Namespace, p.Name, statusReason(p), ownerName(p.OwnerReferences), action})
+	}
+	for _, s := range plan.Skip {
+		data = append(data, []string{s.Pod.Namespace, s.Pod.Name, statusReason(s.Pod), ownerName(s.Pod.OwnerReferences), "skip: " + s.Reason})
+	}
+	table.Bulk(data)
+	table.Render()
+	return nil
+}
+
+// EvictWithRetry evicts the pods, trying the ones a PodDisruptionBudget
+// blocks again every retry until they go or ctx is done. Pods still
+// blocked then are reported as such.
+func EvictWithRetry(ctx context.Context, client kubernetes.Interface, pods []v1.Pod, opts PodActionOpts, retry time.Duration) []PodActionResult {
+	results := make([]PodActionResult, len(pods))
+	pending := make([]int, len(pods))
+	for i := range pods {
+		pending[i] = i
+	}
+	for {
+		var blocked []int
+		for _, i := range pending {
+			r := EvictPods(ctx, client, pods[i:i+1], opts)[0]
+			if results[i].Blocked && ctx.Err() != nil {
+				// Out of time while retrying, the pod is still blocked
+				return results
+			}
+			results[i] = r
+			if results[i].Blocked {
+				blocked = append(blocked, i)
+			}
+		}
+		if len(blocked) == 0 || retry <= 0 {
+			return results
+		}
+		select {
+		case <-ctx.Done():
+			return results
+		case <-time.After(retry):
+		}
+		pending = blocked
+	}
+}
+
+// Drained is true once none of the pods is left on the node.
+func Drained(pods []v1.Pod) func([]v1.Pod) (bool, error) {
+	evicted := make(map[types.UID]bool, len(pods))
+	for _, p := range pods {
+		evicted[p.UID] = true
+	}
+	return func(current []v1.Pod) (bool, error) {
+		for _, p := range current {
+			if evicted[p.UID] {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+// DrainError is nil when every pod was evicted, and else says how many
+// were not.
+func DrainError(results []PodActionResult) error {
+	failed, blocked := 0, 0
+	for _, r := range results {
+		if r.Failed() {
+			failed++
+		}
+		if r.Blocked {
+			blocked++
+		}
+	}
+	switch {
+	case failed == 0:
+		return nil
+	case blocked == failed:
+		return fmt.Errorf("%d pods are still blocked by PodDisruptionBudgets", blocked)
+	}
+	return fmt.Errorf("could not evict %d of %d pods", failed, len(results))
+}
@@ -0,0 +1,110 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCordon(t *testing.T) {
+	client := fake.NewClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}})
+	ctx := context.Background()
+
+	if changed, err := Cordon(ctx, client, "worker-1", true, false); err != nil || !changed {
+		t.Fatalf("Cordon = %v, %v", changed, err)
+	}
+	node, _ := client.CoreV1().Nodes().Get(ctx, "worker-1", metav1.GetOptions{})
+	if !node.Spec.Unschedulable {
+		t.Error("node is still schedulable")
+	}
+	if changed, err := Cordon(ctx, client, "worker-1", true, false); err != nil || changed {
+		t.Errorf("Cordon(cordoned) = %v, %v", changed, err)
+	}
+	if changed, err := Cordon(ctx, client, "worker-1", false, false); err != nil || !changed {
+		t.Errorf("uncordon = %v, %v", changed, err)
+	}
+	if _, err := Cordon(ctx, client, "worker-9", true, false); !apierrors.IsNotFound(err) {
+		t.Errorf("Cordon(missing node) error = %v", err)
+	}
+}
+
+func TestPlanDrain(t *testing.T) {
+	mirror := treePod("kube-apiserver-worker-1", nil)
+	mirror.Annotations = map[string]string{v1.MirrorPodAnnotationKey: "abc"}
+	pods := []v1.Pod{
+		treePod("api-7d9f-a", ownedBy("apps/v1", "ReplicaSet", "api-7d9f")),
+		treePod("node-exporter-x", ownedBy("apps/v1", "DaemonSet", "node-exporter")),
+		mirror,
+		treePod("standalone", nil),
+	}
+	plan := PlanDrain(pods)
+	if got := strings.Join(podNames(plan.Evict), " "); got != "api-7d9f-a standalone" {
+		t.Errorf("evict = %s", got)
+	}
+	var skipped []string
+	for _, s := range plan.Skip {
+		skipped = append(skipped, s.Pod.Name+": "+s.Reason)
+	}
+	if got := strings.Join(skipped, ", "); got != "node-exporter-x: DaemonSet pod, kube-apiserver-worker-1: mirror pod" {
+		t.Errorf("skip = %s", got)
+	}
+}
+
+func TestEvictWithRetry(t *testing.T) {
+	pods := []v1.Pod{
+		labeledPod("api-1", "api", true, time.Hour),
+		labeledPod("db-1", "db", true, time.Hour),
+	}
+	client := fake.NewClientset()
+	attempts := map[string]int{}
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction).Name
+		attempts[name]++
+		// The budget allows db-1 to go once api-1 has
+		if name == "db-1" && attempts[name] < 3 {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+		}
+		return true, nil, nil
+	})
+
+	results := EvictWithRetry(context.Background(), client, pods, PodActionOpts{GracePeriod: -1}, time.Millisecond)
+	if err := DrainError(results); err != nil || attempts["api-1"] != 1 || attempts["db-1"] != 3 {
+		t.Errorf("EvictWithRetry = %v, attempts %v", err, attempts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	attempts["db-1"] = -100
+	results = EvictWithRetry(ctx, client, pods[1:], PodActionOpts{GracePeriod: -1}, time.Millisecond)
+	if err := DrainError(results); err == nil || !results[0].Blocked || !strings.Contains(err.Error(), "1 pods are still blocked") {
+		t.Errorf("EvictWithRetry(timeout) = %v, %+v", err, results)
+	}
+}
+
+func TestDrained(t *testing.T) {
+	a := labeledPod("api-1", "api", true, time.Hour)
+	a.UID = "uid-a"
+	ds := labeledPod("node-exporter-x", "node-exporter", true, time.Hour)
+	ds.UID = "uid-ds"
+	// A replaced StatefulSet pod comes back with the same name but another
+	// UID
+	replaced := a
+	replaced.UID = "uid-a2"
+
+	drained := Drained([]v1.Pod{a})
+	if ok, _ := drained([]v1.Pod{a, ds}); ok {
+		t.Error("drained with the pod still there")
+	}
+	if ok, _ := drained([]v1.Pod{ds, replaced}); !ok {
+		t.Error("not drained once the pod is gone")
+	}
+}
@@ -0,0 +1,157 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// DefaultContainerAnnotation names the container kubectl picks when none
+// is given.
+const DefaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+type ExecOpts struct {
+	Namespace string
+	Pod       string
+	Container string
+	Command   []string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	TTY    bool
+	// Resize reports terminal size changes when TTY is set
+	Resize remotecommand.TerminalSizeQueue
+}
+
+// Executor runs a command in a container.
+type Executor interface {
+	Exec(ctx context.Context, opts ExecOpts) error
+}
+
+// RemoteExecutor streams exec sessions over WebSocket, falling back to SPDY
+// for apiservers that do not support it.
+type RemoteExecutor struct {
+	Config *rest.Config
+	Client kubernetes.Interface
+}
+
+func (e RemoteExecutor) Exec(ctx context.Context, opts ExecOpts) error {
+	req := e.Client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(opts.Namespace).
+		Name(opts.Pod).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: opts.Container,
+			Command:   opts.Command,
+			Stdin:     opts.Stdin != nil,
+			Stdout:    opts.Stdout != nil,
+			// With a TTY stderr is merged into stdout
+			Stderr: opts.Stderr != nil && !opts.TTY,
+			TTY:    opts.TTY,
+		}, scheme.ParameterCodec)
+
+	exec, err := streamExecutor(e.Config, req.URL())
+	if err != nil {
+		return err
+	}
+	return exec.StreamWithContext(ctx, streamOptions(opts))
+}
+
+func streamOptions(opts ExecOpts) remotecommand.StreamOptions {
+	streamOpts := remotecommand.StreamOptions{
+		Stdin:             opts.Stdin,
+		Stdout:            opts.Stdout,
+		Tty:               opts.TTY,
+		TerminalSizeQueue: opts.Resize,
+	}
+	if !opts.TTY {
+		streamOpts.Stderr = opts.Stderr
+	}
+	return streamOpts
+}
+
+// streamExecutor connects to an exec or attach URL, preferring WebSocket.
+func streamExecutor(config *rest.Config, u *url.URL) (remotecommand.Executor, error) {
+	ws, err := remotecommand.NewWebSocketExecutor(config, "GET", u.String())
+	if err != nil {
+		return nil, err
+	}
+	spdy, err := remotecommand.NewSPDYExecutor(config, "POST", u)
+	if err != nil {
+		return nil, err
+	}
+	return remotecommand.NewFallbackExecutor(ws, spdy, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+}
+
+// ExecContainer picks the container to exec into: the one asked for, else
+// the one named by the default-container annotation, else the first. The
+// note explains a default pick among several containers and is empty
+// otherwise.
+func ExecContainer(pod *v1.Pod, container string) (name, note string, err error) {
+	names := make([]string, 0, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		names = append(names, c.Name)
+	}
+	if container != "" {
+		if slices.Contains(names, container) {
+			return container, "", nil
+		}
+		for _, c := range pod.Spec.EphemeralContainers {
+			if c.Name == container {
+				return container, "", nil
+			}
+		}
+		return "", "", fmt.Errorf("container %q not found in pod %s, choose one of: %s", container, pod.Name, strings.Join(names, ", "))
+	}
+	if len(names) == 0 {
+		return "", "", fmt.Errorf("pod %s has no containers", pod.Name)
+	}
+	if def := pod.Annotations[DefaultContainerAnnotation]; slices.Contains(names, def) {
+		return def, "", nil
+	}
+	if len(names) > 1 {
+		note = fmt.Sprintf("Defaulted container %q out of: %s", names[0], strings.Join(names, ", "))
+	}
+	return names[0], note, nil
+}
+
+// CheckExecutable explains why a command cannot run in the container, or
+// returns nil when it can.
+func CheckExecutable(pod *v1.Pod, container string) error {
+	switch pod.Status.Phase {
+	case v1.PodSucceeded, v1.PodFailed:
+		return fmt.Errorf("cannot exec into pod %s: it has completed (%s)", pod.Name, statusReason(*pod))
+	case v1.PodPending:
+		return fmt.Errorf("cannot exec into pod %s: it is not running yet (%s); try `kubepeek why pod %s`", pod.Name, statusReason(*pod), pod.Name)
+	}
+	statuses := append(slices.Clone(pod.Status.ContainerStatuses), pod.Status.EphemeralContainerStatuses...)
+	for _, s := range statuses {
+		if s.Name != container {
+			continue
+		}
+		switch {
+		case s.State.Running != nil:
+			return nil
+		case s.State.Waiting != nil:
+			return fmt.Errorf("cannot exec into container %q of pod %s: it is waiting (%s)", container, pod.Name, s.State.Waiting.Reason)
+		case s.State.Terminated != nil:
+			return fmt.Errorf("cannot exec into container %q of pod %s: it has terminated (%s)", container, pod.Name, s.State.Terminated.Reason)
+		}
+	}
+	// No status yet; let the apiserver decide
+	return nil
+}
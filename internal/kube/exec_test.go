@@ -0,0 +1,80 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func execPod(annotations map[string]string, containers ...string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "default", Annotations: annotations},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: c})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, v1.ContainerStatus{
+			Name: c, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+		})
+	}
+	return pod
+}
+
+func TestExecContainer(t *testing.T) {
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		container string
+		want      string
+		note      bool
+		err       string
+	}{
+		{"single container", execPod(nil, "app"), "", "app", false, ""},
+		{"first of several", execPod(nil, "app", "proxy"), "", "app", true, ""},
+		{"annotation", execPod(map[string]string{DefaultContainerAnnotation: "proxy"}, "app", "proxy"), "", "proxy", false, ""},
+		{"stale annotation", execPod(map[string]string{DefaultContainerAnnotation: "gone"}, "app", "proxy"), "", "app", true, ""},
+		{"explicit", execPod(map[string]string{DefaultContainerAnnotation: "proxy"}, "app", "proxy"), "app", "app", false, ""},
+		{"unknown", execPod(nil, "app", "proxy"), "db", "", false, "choose one of: app, proxy"},
+	}
+	for _, tt := range tests {
+		got, note, err := ExecContainer(tt.pod, tt.container)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want || (note != "") != tt.note {
+			t.Errorf("%s: ExecContainer() = %q, %q, %v; want %q (note %v)", tt.name, got, note, err, tt.want, tt.note)
+		}
+	}
+}
+
+func TestCheckExecutable(t *testing.T) {
+	running := execPod(nil, "app")
+	if err := CheckExecutable(running, "app"); err != nil {
+		t.Errorf("CheckExecutable(running) = %v", err)
+	}
+
+	pending := execPod(nil, "app")
+	pending.Status.Phase = v1.PodPending
+	pending.Status.ContainerStatuses[0].State = v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}
+
+	crashing := execPod(nil, "app")
+	crashing.Status.ContainerStatuses[0].State = v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+
+	done := execPod(nil, "app")
+	done.Status.Phase = v1.PodSucceeded
+
+	for pod, want := range map[*v1.Pod]string{
+		pending:  "not running yet (ContainerCreating)",
+		crashing: "waiting (CrashLoopBackOff)",
+		done:     "has completed",
+	} {
+		if err := CheckExecutable(pod, "app"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckExecutable() = %v, want it to mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package kube
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Filter expressions are evaluated client side, after List and on every
+// watch event, for what the apiserver selectors cannot express:
+//
+//	restarts > 3 && status != "Running" && node =~ "worker-.*"
+//	labels.app == api || !(ready >= 1)
+//	cpu > 500m && memory < 1Gi
+//
+// A comparison is FIELD OP VALUE with OP one of == != > >= < <= =~ !~.
+// Comparisons combine with &&, || and !, and group with parentheses.
+// Strings may be quoted; numeric fields take numbers, quantities (500m,
+// 1Gi) or durations (90s, 2h, 3d) depending on the field. A comparison with
+// a value that is missing, like the usage of a pod metrics-server has not
+// reported, is false.
+
+type fieldType int
+
+const (
+	fieldString fieldType = iota
+	fieldNumber
+	fieldQuantity
+	fieldDuration
+)
+
+type field[T any] struct {
+	typ fieldType
+	str func(T) string
+	num func(T) float64
+}
+
+var podFilterFields = map[string]field[v1.Pod]{
+	"name":      {typ: fieldString, str: func(p v1.Pod) string { return p.Name }},
+	"namespace": {typ: fieldString, str: func(p v1.Pod) string { return p.Namespace }},
+	"status":    {typ: fieldString, str: statusReason},
+	"phase":     {typ: fieldString, str: func(p v1.Pod) string { return string(p.Status.Phase) }},
+	"node":      {typ: fieldString, str: func(p v1.Pod) string { return p.Spec.NodeName }},
+	"ip":        {typ: fieldString, str: func(p v1.Pod) string { return p.Status.PodIP }},
+	"restarts": {typ: fieldNumber, num: func(p v1.Pod) float64 {
+		n := 0
+		for _, s := range p.Status.ContainerStatuses {
+			n += int(s.RestartCount)
+		}
+		return float64(n)
+	}},
+	"ready": {typ: fieldNumber, num: func(p v1.Pod) float64 {
+		n := 0
+		for _, s := range p.Status.ContainerStatuses {
+			if s.Ready {
+				n++
+			}
+		}
+		return float64(n)
+	}},
+	"containers": {typ: fieldNumber, num: func(p v1.Pod) float64 { return float64(len(p.Spec.Containers)) }},
+	"age": {typ: fieldDuration, num: func(p v1.Pod) float64 {
+		return float64(time.Since(p.CreationTimestamp.Time))
+	}},
+}
+
+var metricsFilterFields = map[string]field[PodMetricsRow]{
+	"name":      {typ: fieldString, str: func(r PodMetricsRow) string { return r.Name }},
+	"namespace": {typ: fieldString, str: func(r PodMetricsRow) string { return r.Namespace }},
+	// In cores and bytes, to compare with quantities
+	"cpu": {typ: fieldQuantity, num: func(r PodMetricsRow) float64 {
+		if r.CPU == unknownUsage {
+			return math.NaN()
+		}
+		return float64(r.CPUMillis) / 1000
+	}},
+	"memory": {typ: fieldQuantity, num: func(r PodMetricsRow) float64 {
+		if r.Memory == unknownUsage {
+			return math.NaN()
+		}
+		return float64(r.MemoryBytes)
+	}},
+}
+
+// ParsePodFilter compiles a filter expression over pods. Besides the fixed
+// fields, labels.KEY compares a label value.
+func ParsePodFilter(expr string) (func(v1.Pod) bool, error) {
+	return parseFilter(expr, func(name string) (field[v1.Pod], bool) {
+		if key, ok := strings.CutPrefix(name, "labels."); ok {
+			return field[v1.Pod]{typ: fieldString, str: func(p v1.Pod) string { return p.Labels[key] }}, true
+		}
+		f, ok := podFilterFields[name]
+		return f, ok
+	}, append(slices.Sorted(maps.Keys(podFilterFields)), "labels.KEY"))
+}
+
+// ParseMetricsFilter compiles a filter expression over `top pods` rows.
+func ParseMetricsFilter(expr string) (func(PodMetricsRow) bool, error) {
+	return parseFilter(expr, func(name string) (field[PodMetricsRow], bool) {
+		f, ok := metricsFilterFields[name]
+		return f, ok
+	}, slices.Sorted(maps.Keys(metricsFilterFields)))
+}
+
+// parseFilter compiles expr with the fields lookup finds; known lists them
+// for errors.
+func parseFilter[T any](expr string, lookup func(string) (field[T], bool), known []string) (func(T) bool, error) {
+	toks, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser[T]{toks: toks, lookup: lookup, known: known}
+	fn, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("filter: unexpected %q at %d", t.text, t.pos)
+	}
+	return fn, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var filterOps = []string{"&&", "||", "==", "!=", ">=", "<=", "=~", "!~", ">", "<", "!"}
+
+func lexFilter(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("filter: unterminated string at %d", i)
+			}
+			str, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("filter: invalid string at %d: %w", i, err)
+			}
+			toks = append(toks, token{tokString, str, i})
+			i = end + 1
+		case isWordChar(c):
+			end := i
+			for end < len(s) && isWordChar(rune(s[end])) {
+				end++
+			}
+			toks = append(toks, token{tokWord, s[i:end], i})
+			i = end
+		default:
+			op := ""
+			for _, o := range filterOps {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("filter: unexpected %q at %d", c, i)
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(s)}), nil
+}
+
+// Words cover field names such as labels.app.kubernetes.io/name and bare
+// values such as Running, 500m or 1.5Gi.
+func isWordChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("._-/", c)
+}
+
+type filterParser[T any] struct {
+	toks   []token
+	pos    int
+	lookup func(string) (field[T], bool)
+	known  []string
+}
+
+func (p *filterParser[T]) peek() token { return p.toks[p.pos] }
+
+func (p *filterParser[T]) peekOp(op string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.text == op
+}
+
+func (p *filterParser[T]) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser[T]) or() (func(T) bool, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(v T) bool { return l(v) || right(v) }
+	}
+	return left, nil
+}
+
+func (p *filterParser[T]) and() (func(T) bool, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(v T) bool { return l(v) && right(v) }
+	}
+	return left, nil
+}
+
+func (p *filterParser[T]) unary() (func(T) bool, error) {
+	switch t := p.peek(); {
+	case t.kind == tokOp && t.text == "!":
+		p.next()
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v T) bool { return !inner(v) }, nil
+	case t.kind == tokLParen:
+		p.next()
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, fmt.Errorf("filter: expected ) at %d, got %q", t.pos, t.text)
+		}
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+func (p *filterParser[T]) comparison() (func(T) bool, error) {
+	name := p.next()
+	if name.kind != tokWord {
+		return nil, fmt.Errorf("filter: expected a field at %d, got %q", name.pos, name.text)
+	}
+	f, ok := p.lookup(name.text)
+	if !ok {
+		return nil, fmt.Errorf("filter: unknown field %q (known: %s)", name.text, strings.Join(p.known, ", "))
+	}
+	op := p.next()
+	if op.kind != tokOp || op.text == "&&" || op.text == "||" || op.text == "!" {
+		return nil, fmt.Errorf("filter: expected a comparison after %q at %d, got %q", name.text, op.pos, op.text)
+	}
+	lit := p.next()
+	if lit.kind != tokWord && lit.kind != tokString {
+		return nil, fmt.Errorf("filter: expected a value after %q at %d, got %q", op.text, lit.pos, lit.text)
+	}
+
+	if f.typ == fieldString {
+		return compareString(f.str, op.text, lit.text)
+	}
+	want, err := parseNumber(f.typ, lit.text)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %s: %w", name.text, err)
+	}
+	return compareNumber(f.num, op.text, want)
+}
+
+func compareString[T any](get func(T) string, op, want string) (func(T) bool, error) {
+	switch op {
+	case "==":
+		return func(v T) bool { return get(v) == want }, nil
+	case "!=":
+		return func(v T) bool { return get(v) != want }, nil
+	case "=~", "!~":
+		re, err := regexp.Compile("^(?:" + want + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("filter: %w", err)
+		}
+		match := op == "=~"
+		return func(v T) bool { return re.MatchString(get(v)) == match }, nil
+	}
+	return nil, fmt.Errorf("filter: %s needs a numeric field", op)
+}
+
+func compareNumber[T any](get func(T) float64, op string, want float64) (func(T) bool, error) {
+	switch op {
+	case "==":
+		return func(v T) bool { return get(v) == want }, nil
+	case "!=":
+		// NaN, a missing value, is unequal to anything but still not a match
+		return func(v T) bool { x := get(v); return !math.IsNaN(x) && x != want }, nil
+	case ">":
+		return func(v T) bool { return get(v) > want }, nil
+	case ">=":
+		return func(v T) bool { return get(v) >= want }, nil
+	case "<":
+		return func(v T) bool { return get(v) < want }, nil
+	case "<=":
+		return func(v T) bool { return get(v) <= want }, nil
+	}
+	return nil, fmt.Errorf("filter: %s needs a string field", op)
+}
+
+func parseNumber(typ fieldType, s string) (float64, error) {
+	switch typ {
+	case fieldQuantity:
+		q, err := resource.ParseQuantity(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid quantity %q", s)
+		}
+		return q.AsApproximateFloat64(), nil
+	case fieldDuration:
+		if days, ok := strings.CutSuffix(s, "d"); ok {
+			n, err := strconv.ParseFloat(days, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return n * float64(24*time.Hour), nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return float64(d), nil
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,111 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParsePodFilter(t *testing.T) {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "api-1",
+			Namespace:         "shop",
+			Labels:            map[string]string{"app.kubernetes.io/name": "api"},
+			CreationTimestamp: metav1.Time{Time: time.Now().Add(-2 * time.Hour)},
+		},
+		Spec: v1.PodSpec{NodeName: "worker-3"},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{
+				{RestartCount: 4, State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				{RestartCount: 1, Ready: true},
+			},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`restarts > 3 && status != "Running" && node =~ "worker-.*"`, true},
+		{`restarts >= 6`, false},
+		{`status == CrashLoopBackOff`, true},
+		{`node !~ "worker-.*" || ready == 1`, true},
+		{`!(ready >= 1)`, false},
+		{`labels.app.kubernetes.io/name == api`, true},
+		{`labels.team == ""`, true},
+		{`age > 1h && age < 1d`, true},
+		{`namespace == shop && (phase == Pending || name =~ "api-[0-9]+")`, true},
+		{`name =~ api`, false}, // regexes match the whole value
+	}
+	for _, tt := range tests {
+		keep, err := ParsePodFilter(tt.expr)
+		if err != nil {
+			t.Errorf("ParsePodFilter(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := keep(pod); got != tt.want {
+			t.Errorf("ParsePodFilter(%q)(pod) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParsePodFilter_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`restart > 3`, `unknown field "restart"`},
+		{`app == api`, `labels.KEY`},
+		{`restarts > many`, `invalid number "many"`},
+		{`status > 3`, `> needs a numeric field`},
+		{`restarts =~ "1"`, `=~ needs a string field`},
+		{`age > 5x`, `invalid duration`},
+		{`(restarts > 3`, `expected )`},
+		{`restarts > 3 status`, `unexpected "status"`},
+		{`name == "api`, `unterminated string`},
+		{`name =~ "("`, `missing closing )`},
+	}
+	for _, tt := range tests {
+		_, err := ParsePodFilter(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParsePodFilter(%q) error = %v, want it to mention %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestParseMetricsFilter(t *testing.T) {
+	rows := []PodMetricsRow{
+		{Name: "api", CPUMillis: 750, MemoryBytes: 200 << 20},
+		{Name: "db", CPUMillis: 100, MemoryBytes: 2 << 30},
+		{Name: "new", CPU: unknownUsage, Memory: unknownUsage},
+	}
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`cpu > 500m`, []string{"api"}},
+		{`memory >= 1Gi`, []string{"db"}},
+		{`cpu < 1 && memory < 512Mi`, []string{"api"}},
+		// Pods without metrics match no comparison
+		{`cpu < 100m || cpu <= 100m`, []string{"db"}},
+		{`memory != 0`, []string{"api", "db"}},
+	}
+	for _, tt := range tests {
+		keep, err := ParseMetricsFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseMetricsFilter(%q) error = %v", tt.expr, err)
+		}
+		var got []string
+		for _, r := range filterRows(append([]PodMetricsRow(nil), rows...), keep) {
+			got = append(got, r.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ParseMetricsFilter(%q) kept %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+type FleetOpts struct {
+	Command   []string
+	Container string
+	// Concurrency bounds the sessions running at once; 0 means 10
+	Concurrency int
+	// Out receives each pod's output as it arrives, every line prefixed by
+	// the pod. When nil the output is only kept in the results.
+	Out io.Writer
+}
+
+// FleetResult is the outcome of the command on one pod. ExitCode is -1 when
+// the command could not be run at all, and Err says why.
+type FleetResult struct {
+	Pod       string
+	Container string
+	Output    []byte
+	ExitCode  int
+	Err       error
+}
+
+func (r FleetResult) Failed() bool { return r.ExitCode != 0 }
+
+// RunFleet runs the command on all pods concurrently and returns the
+// results in pod order.
+func RunFleet(ctx context.Context, exec Executor, pods []v1.Pod, opts FleetOpts) []FleetResult {
+	limit := opts.Concurrency
+	if limit <= 0 {
+		limit = 10
+	}
+	label := podLabeler(pods)
+	results := make([]FleetResult, len(pods))
+	var outMu sync.Mutex
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := range pods {
+		pod := &pods[i]
+		results[i] = FleetResult{Pod: label(pod), ExitCode: -1}
+		container, _, err := ExecContainer(pod, opts.Container)
+		if err == nil {
+			err = CheckExecutable(pod, container)
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Container = container
+
+		wg.Add(1)
+		go func(r *FleetResult) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				r.Err = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			var buf bytes.Buffer
+			var out io.Writer = &buf
+			var prefixed *prefixWriter
+			if opts.Out != nil {
+				prefixed = &prefixWriter{mu: &outMu, w: opts.Out, prefix: "[" + r.Pod + "] "}
+				out = io.MultiWriter(&buf, prefixed)
+			}
+			// stdout and stderr are copied by separate goroutines
+			out = &lockedWriter{w: out}
+			err := exec.Exec(ctx, ExecOpts{
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				Container: container,
+				Command:   opts.Command,
+				Stdout:    out,
+				Stderr:    out,
+			})
+			if prefixed != nil {
+				prefixed.Flush()
+			}
+			r.Output = buf.Bytes()
+			r.ExitCode, r.Err = exitCode(err)
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// exitCode maps an exec error to the command's exit code; other errors
+// mean the command did not run.
+func exitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	var exit interface{ ExitStatus() int }
+	if errors.As(err, &exit) {
+		return exit.ExitStatus(), nil
+	}
+	return -1, err
+}
+
+// podLabeler names pods by name, or by namespace/name when they span
+// namespaces.
+func podLabeler(pods []v1.Pod) func(*v1.Pod) string {
+	for _, p := range pods {
+		if p.Namespace != pods[0].Namespace {
+			return func(p *v1.Pod) string { return p.Namespace + "/" + p.Name }
+		}
+	}
+	return func(p *v1.Pod) string { return p.Name }
+}
+
+// prefixWriter writes whole lines, each prefixed, so lines of concurrent
+// pods do not interleave.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// Flush writes a last line that has no newline.
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.writeLine(line)
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := io.WriteString(p.w, p.prefix+string(line))
+	return err
+}
+
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(b)
+}
+
+// FleetGroup is a set of pods whose command gave the same output and exit
+// code.
+type FleetGroup struct {
+	Pods     []string
+	Output   string
+	ExitCode int
+	Error    string
+}
+
+// GroupResults collapses identical results, largest groups first.
+func GroupResults(results []FleetResult) []FleetGroup {
+	var groups []FleetGroup
+	index := map[string]int{}
+	for _, r := range results {
+		g := FleetGroup{Output: string(r.Output), ExitCode: r.ExitCode}
+		if r.Err != nil {
+			g.Error = r.Err.Error()
+		}
+		key := fmt.Sprintf("%d\x00%s\x00%s", g.ExitCode, g.Error, g.Output)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, g)
+		}
+		groups[i].Pods = append(groups[i].Pods, r.Pod)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Pods) > len(groups[j].Pods) })
+	return groups
+}
+
+// PrintFleetGroups prints each group once with the pods it covers.
+func PrintFleetGroups(w io.Writer, groups []FleetGroup) error {
+	for i, g := range groups {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		noun := "pods"
+		if len(g.Pods) == 1 {
+			noun = "pod"
+		}
+		status := fmt.Sprintf("exit code %d", g.ExitCode)
+		if g.Error != "" {
+			status = "error: " + g.Error
+		}
+		fmt.Fprintf(w, "=== %d %s, %s: %s\n", len(g.Pods), noun, status, strings.Join(g.Pods, ", "))
+		if g.Output != "" {
+			out := g.Output
+			if !strings.HasSuffix(out, "\n") {
+				out += "\n"
+			}
+			if _, err := io.WriteString(w, out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PrintFleetSummary lists the pods where the command failed, after a
+// streamed run.
+func PrintFleetSummary(w io.Writer, results []FleetResult) error {
+	failed := 0
+	for _, r := range results {
+		if !r.Failed() {
+			continue
+		}
+		failed++
+		if r.Err != nil {
+			fmt.Fprintf(w, "%s: %v\n", r.Pod, r.Err)
+		} else {
+			fmt.Fprintf(w, "%s: exit code %d\n", r.Pod, r.ExitCode)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d of %d pods succeeded\n", len(results)-failed, len(results))
+	return err
+}
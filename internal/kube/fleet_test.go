@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// execFunc adapts a function to the Executor interface.
+type execFunc func(ctx context.Context, opts ExecOpts) error
+
+func (f execFunc) Exec(ctx context.Context, opts ExecOpts) error { return f(ctx, opts) }
+
+func fleetPods() []v1.Pod {
+	var pods []v1.Pod
+	for _, name := range []string{"api-1", "api-2", "api-3", "api-4"} {
+		p := execPod(nil, "app")
+		p.Name = name
+		pods = append(pods, *p)
+	}
+	pods[3].Status.Phase = v1.PodPending
+	return pods
+}
+
+func TestRunFleet(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	exec := execFunc(func(ctx context.Context, opts ExecOpts) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		switch opts.Pod {
+		case "api-2":
+			io.WriteString(opts.Stderr, "cat: /etc/config: No such file")
+			return utilexec.CodeExitError{Err: errors.New("command terminated with exit code 1"), Code: 1}
+		case "api-3":
+			return errors.New("connection refused")
+		}
+		fmt.Fprintf(opts.Stdout, "mode=prod\nlevel=%s\n", "info")
+		return nil
+	})
+
+	var out bytes.Buffer
+	results := RunFleet(context.Background(), exec, fleetPods(), FleetOpts{
+		Command:     []string{"cat", "/etc/config"},
+		Concurrency: 2,
+		Out:         &out,
+	})
+
+	if maxRunning > 2 {
+		t.Errorf("ran %d sessions at once, want at most 2", maxRunning)
+	}
+	codes := []int{}
+	for _, r := range results {
+		codes = append(codes, r.ExitCode)
+	}
+	if fmt.Sprint(codes) != "[0 1 -1 -1]" {
+		t.Errorf("exit codes = %v, want [0 1 -1 -1]", codes)
+	}
+	if results[3].Err == nil || !strings.Contains(results[3].Err.Error(), "not running yet") {
+		t.Errorf("pending pod error = %v", results[3].Err)
+	}
+	for _, line := range []string{"[api-1] mode=prod\n", "[api-1] level=info\n", "[api-2] cat: /etc/config: No such file\n"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output is missing %q:\n%s", line, out.String())
+		}
+	}
+
+	var summary bytes.Buffer
+	PrintFleetSummary(&summary, results)
+	if !strings.Contains(summary.String(), "api-2: exit code 1") || !strings.HasSuffix(summary.String(), "1 of 4 pods succeeded\n") {
+		t.Errorf("summary:\n%s", summary.String())
+	}
+}
+
+func TestGroupResults(t *testing.T) {
+	results := []FleetResult{
+		{Pod: "api-1", Output: []byte("v2\n")},
+		{Pod: "api-2", Output: []byte("v1\n")},
+		{Pod: "api-3", Output: []byte("v2\n")},
+		{Pod: "api-4", Output: []byte("v2\n"), ExitCode: 3},
+	}
+	groups := GroupResults(results)
+	if len(groups) != 3 || strings.Join(groups[0].Pods, ",") != "api-1,api-3" {
+		t.Fatalf("GroupResults() = %+v", groups)
+	}
+
+	var out bytes.Buffer
+	if err := PrintFleetGroups(&out, groups); err != nil {
+		t.Fatal(err)
+	}
+	want := "=== 2 pods, exit code 0: api-1, api-3\nv2\n\n=== 1 pod, exit code 0: api-2\nv1\n\n=== 1 pod, exit code 3: api-4\nv2\n"
+	if out.String() != want {
+		t.Errorf("PrintFleetGroups() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
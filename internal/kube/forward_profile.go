@@ -0,0 +1,293 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ForwardState string
+
+const (
+	ForwardConnecting   ForwardState = "Connecting"
+	ForwardConnected    ForwardState = "Connected"
+	ForwardReconnecting ForwardState = "Reconnecting"
+	ForwardFailed       ForwardState = "Failed"
+)
+
+// ForwardStatus is a row of the `forward up` status table. BytesIn counts
+// what local clients sent to the pod, BytesOut what they got back.
+type ForwardStatus struct {
+	Name      string
+	Pod       string
+	Ports     string
+	State     ForwardState
+	LastError string
+	BytesIn   int64
+	BytesOut  int64
+}
+
+// ForwardProfile runs all the forwards of a profile at once. Each local
+// port is a listener of its own that relays to the forward of the moment,
+// so local ports stay open across failovers and traffic can be counted.
+type ForwardProfile struct {
+	Resolver  PodResolver
+	Forwarder Forwarder
+	Printer   ForwardStatusPrinter
+	// Address is the local address to listen on; "" means localhost
+	Address string
+	// Interval between status refreshes; 0 means 1s
+	Interval time.Duration
+	// Retry is passed on to each PortForward
+	Retry time.Duration
+}
+
+// Run forwards until ctx is done, then closes every listener and
+// connection. Local ports are all opened first, so a busy port fails the
+// whole profile before anything starts.
+func (p ForwardProfile) Run(ctx context.Context, namespace string, specs []ForwardSpec) error {
+	addr := p.Address
+	if addr == "" {
+		addr = "localhost"
+	}
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	forwards := make([]*profileForward, len(specs))
+	closeAll := func() {
+		for _, f := range forwards {
+			if f != nil {
+				f.close()
+			}
+		}
+	}
+	for i, spec := range specs {
+		f, err := newProfileForward(addr, spec)
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("%s: %w", spec.DisplayName(), err)
+		}
+		forwards[i] = f
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, f := range forwards {
+		for _, px := range f.proxies {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				px.serve(ctx)
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.run(ctx, p, namespace)
+		}()
+	}
+
+	snapshot := func() []ForwardStatus {
+		rows := make([]ForwardStatus, len(forwards))
+		for i, f := range forwards {
+			rows[i] = f.status()
+		}
+		return rows
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Printer.Print(snapshot()); err != nil {
+			cancel()
+			closeAll()
+			wg.Wait()
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			closeAll()
+			wg.Wait()
+			return nil
+		}
+	}
+}
+
+type profileForward struct {
+	spec    ForwardSpec
+	pairs   []PortPair
+	proxies []*forwardProxy
+
+	mu sync.Mutex
+	st ForwardStatus
+}
+
+func newProfileForward(addr string, spec ForwardSpec) (*profileForward, error) {
+	pairs, err := ParsePortPairs(spec.Ports)
+	if err != nil {
+		return nil, err
+	}
+	f := &profileForward{spec: spec, st: ForwardStatus{
+		Name:  spec.DisplayName(),
+		Ports: strings.Join(spec.Ports, ","),
+		State: ForwardConnecting,
+	}}
+	for i, pair := range pairs {
+		local := pair.Local
+		if local == "" {
+			local = pair.Remote
+		}
+		ln, err := net.Listen("tcp", net.JoinHostPort(addr, local))
+		if err != nil {
+			f.close()
+			return nil, err
+		}
+		f.proxies = append(f.proxies, &forwardProxy{ln: ln})
+		// The forward itself listens on a random port that the proxy
+		// relays to
+		pairs[i].Local = "0"
+	}
+	f.pairs = pairs
+	return f, nil
+}
+
+func (f *profileForward) run(ctx context.Context, p ForwardProfile, namespace string) {
+	ns := f.spec.Namespace
+	if ns == "" {
+		ns = namespace
+	}
+	// Pods that are not running yet at start are waited for, like pods
+	// that go away later
+	pf := PortForward{
+		Resolver:   p.Resolver,
+		Forwarder:  p.Forwarder,
+		Log:        io.Discard,
+		Retry:      p.Retry,
+		Notify:     f.notify,
+		KeepTrying: true,
+	}
+	err := pf.Run(ctx, PortForwardOpts{
+		Namespace: ns,
+		Target:    f.spec.Target,
+		Selector:  f.spec.Selector,
+		Ports:     f.pairs,
+	})
+	if err != nil && ctx.Err() == nil {
+		f.mu.Lock()
+		f.st.State, f.st.LastError = ForwardFailed, err.Error()
+		f.mu.Unlock()
+	}
+}
+
+func (f *profileForward) notify(ev ForwardEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ev.Err != nil {
+		f.st.State, f.st.LastError = ForwardReconnecting, ev.Err.Error()
+		// New connections must not dial the port of the dead forward
+		for _, px := range f.proxies {
+			px.backend.Store("")
+		}
+		return
+	}
+	f.st.State, f.st.Pod = ForwardConnected, ev.Pod
+	for i, port := range ev.Local {
+		if i < len(f.proxies) {
+			f.proxies[i].backend.Store(net.JoinHostPort("localhost", strconv.Itoa(int(port))))
+		}
+	}
+}
+
+func (f *profileForward) status() ForwardStatus {
+	f.mu.Lock()
+	st := f.st
+	f.mu.Unlock()
+	for _, px := range f.proxies {
+		st.BytesIn += px.in.Load()
+		st.BytesOut += px.out.Load()
+	}
+	return st
+}
+
+func (f *profileForward) close() {
+	for _, px := range f.proxies {
+		px.ln.Close()
+	}
+}
+
+// forwardProxy relays connections on a fixed local port to the current
+// backend, counting bytes both ways.
+type forwardProxy struct {
+	ln      net.Listener
+	backend atomic.Value // host:port
+	in, out atomic.Int64
+}
+
+func (px *forwardProxy) serve(ctx context.Context) {
+	var conns sync.WaitGroup
+	defer conns.Wait()
+	for {
+		conn, err := px.ln.Accept()
+		if err != nil {
+			return
+		}
+		conns.Add(1)
+		go func() {
+			defer conns.Done()
+			px.relay(ctx, conn)
+		}()
+	}
+}
+
+func (px *forwardProxy) relay(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	backend, _ := px.backend.Load().(string)
+	if backend == "" {
+		// Not connected yet
+		return
+	}
+	var d net.Dialer
+	up, err := d.DialContext(ctx, "tcp", backend)
+	if err != nil {
+		return
+	}
+	defer up.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(countingWriter{up, &px.in}, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(countingWriter{conn, &px.out}, up)
+		done <- struct{}{}
+	}()
+	// Either side closing, or shutdown, ends the connection
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	conn.Close()
+	up.Close()
+	<-done
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n.Add(int64(n))
+	return n, err
+}
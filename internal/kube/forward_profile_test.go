@@ -0,0 +1,153 @@
+package kube
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// echoForwarder stands in for the tunnel with a local echo server.
+type echoForwarder struct{}
+
+func (echoForwarder) Forward(ctx context.Context, ns, pod string, ports []string, ready func([]uint16)) error {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	ready([]uint16{uint16(ln.Addr().(*net.TCPAddr).Port)})
+	<-ctx.Done()
+	return nil
+}
+
+type statusRecorder struct {
+	mu   sync.Mutex
+	last []ForwardStatus
+}
+
+func (r *statusRecorder) Print(rows []ForwardStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = rows
+	return nil
+}
+
+func (r *statusRecorder) rows() []ForwardStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+}
+
+func TestForwardProfile_Run(t *testing.T) {
+	web := labeledPod("web-1", "web", true, time.Minute)
+	web.Spec.Containers = []v1.Container{{Ports: []v1.ContainerPort{{Name: "web", ContainerPort: 8080}}}}
+	printer := &statusRecorder{}
+	profile := ForwardProfile{
+		Resolver:  newTestResolver(web),
+		Forwarder: echoForwarder{},
+		Printer:   printer,
+		Interval:  5 * time.Millisecond,
+		Retry:     time.Millisecond,
+	}
+	local := freePort(t)
+	specs := []ForwardSpec{
+		{Name: "web", Target: "services/web", Ports: []string{local + ":http"}},
+		{Selector: "app=missing", Ports: []string{freePort(t) + ":80"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- profile.Run(ctx, "default", specs) }()
+
+	waitFor := func(what string, ok func([]ForwardStatus) bool) []ForwardStatus {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if rows := printer.rows(); len(rows) == 2 && ok(rows) {
+				return rows
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s, last status %+v", what, printer.rows())
+		return nil
+	}
+	waitFor("connected", func(rows []ForwardStatus) bool { return rows[0].State == ForwardConnected })
+
+	conn, err := net.Dial("tcp", "localhost:"+local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(conn, "hello")
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hello" {
+		t.Fatalf("echo = %q, %v", buf, err)
+	}
+	conn.Close()
+
+	rows := waitFor("bytes", func(rows []ForwardStatus) bool { return rows[0].BytesIn == 5 && rows[0].BytesOut == 5 })
+	if rows[0].Pod != "web-1" || rows[0].Name != "web" {
+		t.Errorf("status = %+v", rows[0])
+	}
+	// No pod matches the selector at start, so that forward waits for one
+	if rows[1].Name != "app=missing" || rows[1].State != ForwardReconnecting || !strings.Contains(rows[1].LastError, "app=missing") {
+		t.Errorf("status = %+v", rows[1])
+	}
+	late := labeledPod("late-1", "missing", true, 0)
+	if _, err := profile.Resolver.Pods.(ClientGoSource).Client.CoreV1().Pods("default").Create(ctx, &late, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("the late pod", func(rows []ForwardStatus) bool { return rows[1].State == ForwardConnected && rows[1].Pod == "late-1" })
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	if _, err := net.Dial("tcp", "localhost:"+local); err == nil {
+		t.Error("local port still open after Run returned")
+	}
+}
+
+func TestForwardProfile_PortInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	busy := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+
+	profile := ForwardProfile{Resolver: newTestResolver(), Forwarder: echoForwarder{}, Printer: &statusRecorder{}}
+	err = profile.Run(context.Background(), "default", []ForwardSpec{{Name: "db", Target: "db-0", Ports: []string{busy + ":5432"}}})
+	if err == nil || !strings.HasPrefix(err.Error(), "db: ") {
+		t.Errorf("Run() error = %v, want the busy port reported for db", err)
+	}
+}
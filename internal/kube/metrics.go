@@ -2,7 +2,10 @@ package kube
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,8 +19,13 @@ type MetricsSource struct {
 	MetricsClient metricsclientset.Interface
 }
 
+// UsageSource reports the resource usage of the pods in a namespace.
+type UsageSource interface {
+	PodUsage(ctx context.Context, ns string) ([]PodMetricsRow, error)
+}
+
 type MetricsController struct {
-	Source  MetricsSource
+	Source  UsageSource
 	Printer MetricsPrinterInterface
 }
 
@@ -26,23 +34,71 @@ type MetricsPrinterInterface interface {
 	Refresh([]PodMetricsRow) error
 }
 
+const unknownUsage = "<unknown>"
+
 type MetricsOpts struct {
 	Namespace string
+	// Watch polls the usage every Interval until the context is done or
+	// the source returns io.EOF
+	Watch    bool
+	Interval time.Duration
+	// Filter drops the rows it returns false for. Nil keeps every row.
+	Filter func(PodMetricsRow) bool
 }
 
 type PodMetricsRow struct {
-	Name   string
-	CPU    string
-	Memory string
+	Name      string
+	Namespace string
+	CPU       string
+	Memory    string
+	// Raw usage for sorting and filtering; not part of the output
+	CPUMillis   int64 `json:"-"`
+	MemoryBytes int64 `json:"-"`
 }
 
 func (c MetricsController) Run(ctx context.Context, opts MetricsOpts) error {
-	podList, err := c.Source.Client.CoreV1().Pods(opts.Namespace).List(ctx, metav1.ListOptions{})
+	rows, err := c.Source.PodUsage(ctx, opts.Namespace)
 	if err != nil {
 		return err
 	}
+	if err := c.Printer.Print(filterRows(rows, opts.Filter)); err != nil {
+		return err
+	}
+	if !opts.Watch {
+		return nil
+	}
+
+	// Metrics cannot be watched, poll them
+	for {
+		if opts.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(opts.Interval):
+			}
+		}
+		rows, err := c.Source.PodUsage(ctx, opts.Namespace)
+		if errors.Is(err, io.EOF) || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.Printer.Refresh(filterRows(rows, opts.Filter)); err != nil {
+			return err
+		}
+	}
+}
 
-	metricsList, err := c.Source.MetricsClient.MetricsV1beta1().PodMetricses(opts.Namespace).List(ctx, metav1.ListOptions{})
+// PodUsage joins the pods in ns with their metrics. Pods without metrics
+// are reported as <unknown>.
+func (s MetricsSource) PodUsage(ctx context.Context, ns string) ([]PodMetricsRow, error) {
+	podList, err := s.Client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	metricsList, err := s.MetricsClient.MetricsV1beta1().PodMetricses(ns).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		// In real usage, this would be a connection error to metrics-server
 		// For tests, we'll still get empty list but no error
@@ -51,46 +107,60 @@ func (c MetricsController) Run(ctx context.Context, opts MetricsOpts) error {
 
 	metricsMap := make(map[string]metricsv1beta1.PodMetrics)
 	for _, metrics := range metricsList.Items {
-		metricsMap[metrics.Name] = metrics
+		metricsMap[metrics.Namespace+"/"+metrics.Name] = metrics
 	}
 
 	var rows []PodMetricsRow
 	for _, pod := range podList.Items {
-		metrics, hasMetrics := metricsMap[pod.Name]
-		
-		var cpu, memory string
+		metrics, hasMetrics := metricsMap[pod.Namespace+"/"+pod.Name]
+
+		row := PodMetricsRow{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			CPU:       unknownUsage,
+			Memory:    unknownUsage,
+		}
 		if hasMetrics {
-			cpu, memory = CalculatePodUsage(metrics)
-		} else {
-			cpu, memory = "<unknown>", "<unknown>"
+			row.CPU, row.Memory = CalculatePodUsage(metrics)
+			row.CPUMillis, row.MemoryBytes = podUsage(metrics)
 		}
-
-		rows = append(rows, PodMetricsRow{
-			Name:   pod.Name,
-			CPU:    cpu,
-			Memory: memory,
-		})
+		rows = append(rows, row)
 	}
+	return rows, nil
+}
 
-	return c.Printer.Print(rows)
+func filterRows(rows []PodMetricsRow, keep func(PodMetricsRow) bool) []PodMetricsRow {
+	if keep == nil {
+		return rows
+	}
+	out := rows[:0]
+	for _, r := range rows {
+		if keep(r) {
+			out = append(out, r)
+		}
+	}
+	return out
 }
 
 func CalculatePodUsage(metrics metricsv1beta1.PodMetrics) (string, string) {
-	var totalCPU, totalMemory int64
+	totalCPU, totalMemory := podUsage(metrics)
+
+	cpuStr := fmt.Sprintf("%dm", totalCPU)
+	memoryStr := formatMemory(totalMemory)
 
+	return cpuStr, memoryStr
+}
+
+func podUsage(metrics metricsv1beta1.PodMetrics) (cpuMillis, memoryBytes int64) {
 	for _, container := range metrics.Containers {
 		if cpu := container.Usage[v1.ResourceCPU]; !cpu.IsZero() {
-			totalCPU += cpu.MilliValue()
+			cpuMillis += cpu.MilliValue()
 		}
 		if memory := container.Usage[v1.ResourceMemory]; !memory.IsZero() {
-			totalMemory += memory.Value()
+			memoryBytes += memory.Value()
 		}
 	}
-
-	cpuStr := fmt.Sprintf("%dm", totalCPU)
-	memoryStr := formatMemory(totalMemory)
-
-	return cpuStr, memoryStr
+	return cpuMillis, memoryBytes
 }
 
 func formatMemory(bytes int64) string {
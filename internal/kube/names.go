@@ -0,0 +1,121 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// NameMatch selects pods by name from positional arguments, which are exact
+// names or shell globs (redis-*), and an optional regular expression. A pod
+// matches if any of them does.
+type NameMatch struct {
+	Exact []string
+	Globs []string
+	Regex *regexp.Regexp
+}
+
+func ParseNameMatch(args []string, regex string) (NameMatch, error) {
+	var m NameMatch
+	for _, a := range args {
+		if !strings.ContainsAny(a, `*?[\`) {
+			m.Exact = append(m.Exact, a)
+			continue
+		}
+		if _, err := path.Match(a, ""); err != nil {
+			return NameMatch{}, fmt.Errorf("invalid pattern %q: %w", a, err)
+		}
+		m.Globs = append(m.Globs, a)
+	}
+	if regex != "" {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return NameMatch{}, fmt.Errorf("invalid --regex: %w", err)
+		}
+		m.Regex = re
+	}
+	return m, nil
+}
+
+func (m NameMatch) IsZero() bool {
+	return len(m.Exact) == 0 && len(m.Globs) == 0 && m.Regex == nil
+}
+
+// ExactOnly reports whether the pods can be fetched by name rather than
+// listed and matched.
+func (m NameMatch) ExactOnly() bool {
+	return len(m.Exact) > 0 && len(m.Globs) == 0 && m.Regex == nil
+}
+
+func (m NameMatch) Matches(name string) bool {
+	if slices.Contains(m.Exact, name) {
+		return true
+	}
+	for _, g := range m.Globs {
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+	}
+	return m.Regex != nil && m.Regex.MatchString(name)
+}
+
+// PodGetter is implemented by sources that can fetch a single pod.
+type PodGetter interface {
+	GetPod(ctx context.Context, ns, name string) (*v1.Pod, error)
+}
+
+// byNameGetter is implemented by sources that wrap another and handle the
+// pods fetched by name as a whole.
+type byNameGetter interface {
+	getByName(ctx context.Context, ns string, opts ListOpts, names []string) (*v1.PodList, error)
+}
+
+// getByName fetches the pods named in m.Exact. Within a namespace it uses
+// Get when the source supports it; across namespaces it lists with a
+// metadata.name field selector. Missing pods are reported together after
+// the ones found.
+func getByName(ctx context.Context, src PodSource, ns string, opts ListOpts, names []string) (*v1.PodList, error) {
+	if g, ok := src.(byNameGetter); ok {
+		return g.getByName(ctx, ns, opts, names)
+	}
+	list := &v1.PodList{}
+	var missing []error
+	for _, name := range names {
+		getter, ok := src.(PodGetter)
+		if ns != "" && ok && opts.LabelSelector == "" && opts.FieldSelector == "" {
+			pod, err := getter.GetPod(ctx, ns, name)
+			if apierrors.IsNotFound(err) {
+				missing = append(missing, err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			list.Items = append(list.Items, *pod)
+			continue
+		}
+
+		byName := opts
+		byName.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		if opts.FieldSelector != "" {
+			byName.FieldSelector = opts.FieldSelector + "," + byName.FieldSelector
+		}
+		found, err := src.List(ctx, ns, byName)
+		if err != nil {
+			return nil, err
+		}
+		if len(found.Items) == 0 && opts.LabelSelector == "" && opts.FieldSelector == "" {
+			missing = append(missing, apierrors.NewNotFound(v1.Resource("pods"), name))
+		}
+		list.Items = append(list.Items, found.Items...)
+	}
+	return list, errors.Join(missing...)
+}
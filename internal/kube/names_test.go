@@ -0,0 +1,95 @@
+package kube
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestNameMatch(t *testing.T) {
+	m, err := ParseNameMatch([]string{"nginx", "redis-*"}, "^api-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ExactOnly() {
+		t.Error("ExactOnly() = true with globs and a regex")
+	}
+	for name, want := range map[string]bool{
+		"nginx":       true,
+		"nginx-2":     false,
+		"redis-0":     true,
+		"api-7d9f":    true,
+		"my-api-7d9f": false,
+	} {
+		if got := m.Matches(name); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if _, err := ParseNameMatch([]string{"redis-[0"}, ""); err == nil {
+		t.Error("ParseNameMatch() error = nil for a malformed glob")
+	}
+	if _, err := ParseNameMatch(nil, "("); err == nil {
+		t.Error("ParseNameMatch() error = nil for a malformed regex")
+	}
+}
+
+func TestController_RunNames(t *testing.T) {
+	src, err := NewFileSource(io.Discard, writeBundle(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("exact names in a namespace", func(t *testing.T) {
+		printer := &mockPrinter{}
+		err := Controller{Source: src, CurrentPrinter: printer}.Run(context.Background(), RunOpts{
+			Namespace: "shop",
+			Names:     NameMatch{Exact: []string{"db-0", "missing", "api"}},
+		})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("Run() error = %v, want NotFound for the missing pod", err)
+		}
+		if got := rowNames(printer.lastRows); len(got) != 2 || got[0] != "shop/api" || got[1] != "shop/db-0" {
+			t.Errorf("Run() rows = %v, want the two pods found", got)
+		}
+	})
+
+	t.Run("exact name across namespaces", func(t *testing.T) {
+		printer := &mockPrinter{}
+		err := Controller{Source: src, CurrentPrinter: printer}.Run(context.Background(), RunOpts{
+			Names: NameMatch{Exact: []string{"web"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rowNames(printer.lastRows); len(got) != 1 || got[0] != "default/web" {
+			t.Errorf("Run() rows = %v, want [default/web]", got)
+		}
+	})
+
+	t.Run("glob in watch mode", func(t *testing.T) {
+		watcher := &mockWatcher{resultChan: make(chan watch.Event, 2)}
+		redis, nginx := namedPod("default", "redis-1"), namedPod("default", "nginx")
+		watcher.resultChan <- watch.Event{Type: EventTypeAdded, Object: &nginx}
+		watcher.resultChan <- watch.Event{Type: EventTypeAdded, Object: &redis}
+
+		printer := &mockPrinter{}
+		ctrl := Controller{
+			Source:         &mockPodSource{listResult: &v1.PodList{Items: []v1.Pod{namedPod("default", "redis-0"), namedPod("default", "api")}}, watchResult: watcher},
+			CurrentPrinter: printer,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := ctrl.Run(ctx, RunOpts{Watch: true, Names: NameMatch{Globs: []string{"redis-*"}}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := rowNames(printer.lastRows); len(got) != 2 || got[0] != "default/redis-0" || got[1] != "default/redis-1" {
+			t.Errorf("Run() rows = %v, want the redis pods only", got)
+		}
+	})
+}
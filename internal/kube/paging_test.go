@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// pagingClient serves pods a..e two at a time. When expire is set, the
+// second continue token is rejected as expired.
+func pagingClient(expire bool) (*fake.Clientset, *[]metav1.ListOptions) {
+	var pods []v1.Pod
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		pods = append(pods, namedPod("default", name))
+	}
+	var calls []metav1.ListOptions
+	client := fake.NewClientset()
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		calls = append(calls, opts)
+		if opts.Limit == 0 {
+			return true, &v1.PodList{Items: pods}, nil
+		}
+		// The continue token is the index of the next pod
+		start, _ := strconv.Atoi(opts.Continue)
+		if expire && start == 4 {
+			return true, nil, apierrors.NewResourceExpired("continue token expired")
+		}
+		end := min(start+int(opts.Limit), len(pods))
+		list := &v1.PodList{Items: pods[start:end]}
+		if end < len(pods) {
+			list.Continue = strconv.Itoa(end)
+		}
+		return true, list, nil
+	})
+	return client, &calls
+}
+
+func TestClientGoSource_ListPages(t *testing.T) {
+	for _, expire := range []bool{false, true} {
+		client, calls := pagingClient(expire)
+		var pages [][]string
+		_, err := ClientGoSource{Client: client}.ListPages(context.Background(), "default", ListOpts{Limit: 2}, func(pods []v1.Pod) error {
+			var names []string
+			for _, p := range pods {
+				names = append(names, p.Name)
+			}
+			pages = append(pages, names)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expire=%v: ListPages() error = %v", expire, err)
+		}
+		want := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+		if len(pages) != 3 || strings.Join(pages[2], ",") != "e" || strings.Join(pages[1], ",") != "c,d" {
+			t.Errorf("expire=%v: pages = %v, want %v", expire, pages, want)
+		}
+		if expire && (*calls)[len(*calls)-1].Limit != 0 {
+			t.Errorf("expected a full list after the token expired, calls = %+v", *calls)
+		}
+	}
+}
+
+func TestController_RunStreamsPages(t *testing.T) {
+	client, calls := pagingClient(false)
+	var buf bytes.Buffer
+	printer := NewStreamingTablePrinter(&buf)
+	printer.Columns = []string{"NAME", "STATUS"}
+
+	err := Controller{Source: ClientGoSource{Client: client}, CurrentPrinter: printer}.Run(context.Background(), RunOpts{
+		Namespace: "default",
+		ListOpts:  ListOpts{Limit: 2},
+		Filter:    func(p v1.Pod) bool { return p.Name != "c" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 3 {
+		t.Errorf("List called %d times, want 3 pages", len(*calls))
+	}
+	out := buf.String()
+	if strings.Count(out, "NAME") != 1 {
+		t.Errorf("expected a single header:\n%s", out)
+	}
+	for _, name := range []string{"a", "b", "d", "e"} {
+		if !strings.Contains(out, "│ "+name+" ") {
+			t.Errorf("row %s missing:\n%s", name, out)
+		}
+	}
+	if strings.Contains(out, "│ c ") {
+		t.Errorf("filtered row c printed:\n%s", out)
+	}
+}
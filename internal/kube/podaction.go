@@ -0,0 +1,160 @@
+package kube
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SelectPods returns the pods `get pods` would list with the same options,
+// sorted by namespace and name. Exact names that do not exist are an
+// error, so nothing is acted on by mistake.
+func SelectPods(ctx context.Context, src PodSource, ns string, opts ListOpts, names NameMatch, filter func(v1.Pod) bool) ([]v1.Pod, error) {
+	keep := filter
+	if !names.IsZero() {
+		keep = func(p v1.Pod) bool {
+			return names.Matches(p.Name) && (filter == nil || filter(p))
+		}
+	}
+	var list *v1.PodList
+	var err error
+	if names.ExactOnly() {
+		list, err = getByName(ctx, src, ns, opts, names.Exact)
+	} else {
+		list, err = src.List(ctx, ns, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pods := filterPods(list.Items, keep)
+	slices.SortFunc(pods, func(a, b v1.Pod) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	return pods, nil
+}
+
+type PodActionOpts struct {
+	// GracePeriod in seconds; negative leaves the pod's own
+	GracePeriod int64
+	// Force kills the pods right away, skipping graceful termination
+	Force bool
+	// DryRun has the apiserver validate the request without persisting it
+	DryRun bool
+}
+
+func (o PodActionOpts) deleteOptions(pod v1.Pod) *metav1.DeleteOptions {
+	// The UID precondition makes sure the pod acted on is the one previewed,
+	// not a new pod that took its name
+	uid := pod.UID
+	opts := &metav1.DeleteOptions{}
+	if uid != "" {
+		opts.Preconditions = &metav1.Preconditions{UID: &uid}
+	}
+	switch {
+	case o.Force:
+		opts.GracePeriodSeconds = new(int64)
+	case o.GracePeriod >= 0:
+		grace := o.GracePeriod
+		opts.GracePeriodSeconds = &grace
+	}
+	if o.DryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	return opts
+}
+
+// PodActionResult is the outcome of deleting or evicting one pod. Blocked
+// is set when a PodDisruptionBudget refused an eviction.
+type PodActionResult struct {
+	Namespace string
+	Name      string
+	Result    string
+	Blocked   bool
+	Err       error
+}
+
+func (r PodActionResult) Failed() bool { return r.Err != nil }
+
+// DeletePods deletes the pods one by one, stopping when ctx is done.
+func DeletePods(ctx context.Context, client kubernetes.Interface, pods []v1.Pod, opts PodActionOpts) []PodActionResult {
+	return actOnPods(ctx, pods, opts, "deleted", func(p v1.Pod) error {
+		return client.CoreV1().Pods(p.Namespace).Delete(ctx, p.Name, *opts.deleteOptions(p))
+	})
+}
+
+// EvictPods evicts the pods through the Eviction API, so that
+// PodDisruptionBudgets are honoured.
+func EvictPods(ctx context.Context, client kubernetes.Interface, pods []v1.Pod, opts PodActionOpts) []PodActionResult {
+	return actOnPods(ctx, pods, opts, "evicted", func(p v1.Pod) error {
+		return client.PolicyV1().Evictions(p.Namespace).Evict(ctx, &policyv1.Eviction{
+			ObjectMeta:    metav1.ObjectMeta{Name: p.Name, Namespace: p.Namespace},
+			DeleteOptions: opts.deleteOptions(p),
+		})
+	})
+}
+
+func actOnPods(ctx context.Context, pods []v1.Pod, opts PodActionOpts, done string, act func(v1.Pod) error) []PodActionResult {
+	if opts.DryRun {
+		done += " (server dry run)"
+	}
+	results := make([]PodActionResult, 0, len(pods))
+	for _, p := range pods {
+		r := PodActionResult{Namespace: p.Namespace, Name: p.Name}
+		err := ctx.Err()
+		if err == nil {
+			err = act(p)
+		}
+		switch {
+		case err == nil:
+			r.Result = done
+		case apierrors.IsNotFound(err):
+			r.Result = "already gone"
+		case apierrors.IsTooManyRequests(err):
+			r.Result, r.Blocked, r.Err = "blocked by a PodDisruptionBudget", true, err
+		case apierrors.IsConflict(err):
+			r.Result, r.Err = "skipped: replaced since the preview", err
+		default:
+			r.Result, r.Err = "failed: "+err.Error(), err
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// PrintPodActionResults prints a line per pod and a summary, e.g.
+// "Evicted 3 of 5 pods, 2 blocked by PodDisruptionBudgets".
+func PrintPodActionResults(w io.Writer, verb string, results []PodActionResult) error {
+	table := newTable(w)
+	table.Header([]string{"NAMESPACE", "NAME", "RESULT"})
+	data := make([][]string, 0, len(results))
+	failed, blocked := 0, 0
+	for _, r := range results {
+		data = append(data, []string{r.Namespace, r.Name, r.Result})
+		if r.Failed() {
+			failed++
+		}
+		if r.Blocked {
+			blocked++
+		}
+	}
+	table.Bulk(data)
+	table.Render()
+
+	summary := fmt.Sprintf("%s %d of %d pods", verb, len(results)-failed, len(results))
+	if blocked > 0 {
+		summary += fmt.Sprintf(", %d blocked by PodDisruptionBudgets", blocked)
+	}
+	if failed > blocked {
+		summary += fmt.Sprintf(", %d failed", failed-blocked)
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
@@ -0,0 +1,122 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSelectPods(t *testing.T) {
+	api1 := labeledPod("api-1", "api", true, time.Hour)
+	api2 := labeledPod("api-2", "api", false, time.Hour)
+	web := labeledPod("web-1", "web", true, time.Hour)
+	client := fake.NewSimpleClientset(&api2, &web, &api1)
+	src := ClientGoSource{Client: client}
+
+	names, _ := ParseNameMatch([]string{"api-*"}, "")
+	pods, err := SelectPods(context.Background(), src, "default", ListOpts{}, names, nil)
+	if err != nil || len(pods) != 2 || pods[0].Name != "api-1" || pods[1].Name != "api-2" {
+		t.Errorf("SelectPods(api-*) = %v, %v", podNames(pods), err)
+	}
+
+	pods, err = SelectPods(context.Background(), src, "default", ListOpts{LabelSelector: "app=api"}, NameMatch{}, HasProblems)
+	if err != nil || len(pods) != 1 || pods[0].Name != "api-2" {
+		t.Errorf("SelectPods(app=api, problems) = %v, %v", podNames(pods), err)
+	}
+
+	names, _ = ParseNameMatch([]string{"api-1", "api-9"}, "")
+	if _, err := SelectPods(context.Background(), src, "default", ListOpts{}, names, nil); !apierrors.IsNotFound(err) {
+		t.Errorf("SelectPods(missing name) error = %v, want not found", err)
+	}
+}
+
+func podNames(pods []v1.Pod) []string {
+	names := make([]string, len(pods))
+	for i, p := range pods {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestEvictPods(t *testing.T) {
+	pods := []v1.Pod{
+		labeledPod("api-1", "api", true, time.Hour),
+		labeledPod("api-2", "api", true, time.Hour),
+		labeledPod("api-3", "api", true, time.Hour),
+		labeledPod("api-4", "api", true, time.Hour),
+	}
+	client := fake.NewSimpleClientset()
+	var options []*metav1.DeleteOptions
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+		options = append(options, eviction.DeleteOptions)
+		switch name := eviction.Name; name {
+		case "api-2":
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+		case "api-3":
+			return true, nil, apierrors.NewNotFound(v1.Resource("pods"), name)
+		case "api-4":
+			return true, nil, apierrors.NewInternalError(context.DeadlineExceeded)
+		}
+		return true, nil, nil
+	})
+	pods[0].UID = "uid-1"
+
+	results := EvictPods(context.Background(), client, pods, PodActionOpts{GracePeriod: 30, DryRun: true})
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Name+": "+r.Result)
+	}
+	want := []string{
+		"api-1: evicted (server dry run)",
+		"api-2: blocked by a PodDisruptionBudget",
+		"api-3: already gone",
+		"api-4: failed: Internal error occurred: context deadline exceeded",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if opts := options[0]; *opts.GracePeriodSeconds != 30 || *opts.Preconditions.UID != "uid-1" || opts.DryRun[0] != metav1.DryRunAll {
+		t.Errorf("delete options = %+v", opts)
+	}
+
+	var out bytes.Buffer
+	PrintPodActionResults(&out, "Evicted", results)
+	if !strings.Contains(out.String(), "Evicted 2 of 4 pods, 1 blocked by PodDisruptionBudgets, 1 failed") {
+		t.Errorf("output:\n%s", out.String())
+	}
+}
+
+func TestDeletePods_Force(t *testing.T) {
+	pod := labeledPod("api-1", "api", true, time.Hour)
+	client := fake.NewSimpleClientset(&pod)
+	var grace *int64
+	client.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		grace = action.(k8stesting.DeleteAction).GetDeleteOptions().GracePeriodSeconds
+		return false, nil, nil
+	})
+
+	results := DeletePods(context.Background(), client, []v1.Pod{pod}, PodActionOpts{GracePeriod: -1, Force: true})
+	if results[0].Result != "deleted" || results[0].Failed() {
+		t.Errorf("result = %+v", results[0])
+	}
+	if grace == nil || *grace != 0 {
+		t.Errorf("grace period = %v, want 0 with --force", grace)
+	}
+	if _, err := client.CoreV1().Pods("default").Get(context.Background(), "api-1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("pod still exists: %v", err)
+	}
+}
@@ -12,10 +12,18 @@ type PodSource interface {
 	Watch(ctx context.Context, ns string, opts ListOpts) (watch.Interface, error)
 }
 
+// PagedSource is implemented by sources that can list in chunks, passing
+// each page on as it arrives. It returns the resource version of the list.
+type PagedSource interface {
+	ListPages(ctx context.Context, ns string, opts ListOpts, page func([]v1.Pod) error) (string, error)
+}
+
 type ListOpts struct {
 	LabelSelector   string
 	FieldSelector   string
 	ResourceVersion string
+	// Limit is the page size; 0 lists everything in one request
+	Limit int64
 }
 
 type PodRow struct {
@@ -26,4 +34,10 @@ type PodRow struct {
 	Restarts  string
 	Age       string
 	Node      string
+	// RecentRestarts counts restarts seen while watching; empty otherwise
+	RecentRestarts string `json:",omitempty"`
+	// Owner is the controlling owner as Kind/name; Labels are key=value
+	// pairs joined by commas
+	Owner  string `json:",omitempty"`
+	Labels string `json:",omitempty"`
 }
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func ToRows(pods []v1.Pod) []PodRow {
@@ -14,15 +16,24 @@ func ToRows(pods []v1.Pod) []PodRow {
 			Name:      p.Name,
 			Namespace: p.Namespace,
 			Ready:     readiness(p.Status.ContainerStatuses),
-			Status:    string(p.Status.Phase),
+			Status:    statusReason(p),
 			Restarts:  containerRestarts(p.Status.ContainerStatuses),
 			Age:       calcAge(p.CreationTimestamp.Time),
 			Node:      p.Spec.NodeName,
+			Owner:     ownerName(p.OwnerReferences),
+			Labels:    labels.Set(p.Labels).String(),
 		})
 	}
 	return rows
 }
 
+func ownerName(refs []metav1.OwnerReference) string {
+	if ref := controllerRef(refs); ref != nil {
+		return ref.Kind + "/" + ref.Name
+	}
+	return ""
+}
+
 func readiness(sts []v1.ContainerStatus) string {
 	ready := 0
 	for _, s := range sts {
@@ -33,6 +44,88 @@ func readiness(sts []v1.ContainerStatus) string {
 	return fmt.Sprintf("%d/%d", ready, len(sts))
 }
 
+// statusReason mirrors the STATUS column of `kubectl get pods`: the phase,
+// refined by init container progress, container waiting/terminated reasons
+// and deletion state.
+func statusReason(p v1.Pod) string {
+	reason := string(p.Status.Phase)
+	if p.Status.Reason != "" {
+		reason = p.Status.Reason
+	}
+
+	initializing := false
+	for i, c := range p.Status.InitContainerStatuses {
+		switch {
+		case c.State.Terminated != nil && c.State.Terminated.ExitCode == 0:
+			continue
+		case c.State.Terminated != nil:
+			switch {
+			case c.State.Terminated.Reason != "":
+				reason = "Init:" + c.State.Terminated.Reason
+			case c.State.Terminated.Signal != 0:
+				reason = fmt.Sprintf("Init:Signal:%d", c.State.Terminated.Signal)
+			default:
+				reason = fmt.Sprintf("Init:ExitCode:%d", c.State.Terminated.ExitCode)
+			}
+		case c.State.Waiting != nil && c.State.Waiting.Reason != "" && c.State.Waiting.Reason != "PodInitializing":
+			reason = "Init:" + c.State.Waiting.Reason
+		default:
+			reason = fmt.Sprintf("Init:%d/%d", i, len(p.Spec.InitContainers))
+		}
+		initializing = true
+		break
+	}
+
+	if !initializing {
+		hasRunning := false
+		for i := len(p.Status.ContainerStatuses) - 1; i >= 0; i-- {
+			c := p.Status.ContainerStatuses[i]
+			switch {
+			case c.State.Waiting != nil && c.State.Waiting.Reason != "":
+				reason = c.State.Waiting.Reason
+			case c.State.Terminated != nil && c.State.Terminated.Reason != "":
+				reason = c.State.Terminated.Reason
+			case c.State.Terminated != nil && c.State.Terminated.Signal != 0:
+				reason = fmt.Sprintf("Signal:%d", c.State.Terminated.Signal)
+			case c.State.Terminated != nil:
+				reason = fmt.Sprintf("ExitCode:%d", c.State.Terminated.ExitCode)
+			case c.Ready && c.State.Running != nil:
+				hasRunning = true
+			}
+		}
+		// A completed sidecar next to running containers doesn't make the pod completed
+		if reason == "Completed" && hasRunning {
+			reason = "NotReady"
+			if podReady(p) {
+				reason = "Running"
+			}
+		}
+	}
+
+	if p.DeletionTimestamp != nil {
+		if p.Status.Reason == "NodeLost" {
+			return "Unknown"
+		}
+		return "Terminating"
+	}
+	return reason
+}
+
+func podReady(p v1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	// No condition reported yet: fall back to the container statuses
+	for _, s := range p.Status.ContainerStatuses {
+		if !s.Ready {
+			return false
+		}
+	}
+	return len(p.Status.ContainerStatuses) > 0
+}
+
 func containerRestarts(sts []v1.ContainerStatus) string {
 	r := 0
 	for _, s := range sts {
@@ -120,6 +120,7 @@ func (m *mockPodSource) Watch(ctx context.Context, ns string, opts ListOpts) (wa
 type mockPrinter struct {
 	printCalled   bool
 	refreshCalled bool
+	refreshCount  int
 	printError    error
 	refreshError  error
 	lastRows      []PodRow
@@ -133,6 +134,7 @@ func (m *mockPrinter) Print(rows []PodRow) error {
 
 func (m *mockPrinter) Refresh(rows []PodRow) error {
 	m.refreshCalled = true
+	m.refreshCount++
 	m.lastRows = rows
 	return m.refreshError
 }
@@ -316,3 +318,25 @@ func TestController_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestController_RunUntil(t *testing.T) {
+	starting := labeledPod("api-1", "api", false, 0)
+	ready := labeledPod("api-1", "api", true, 0)
+	watcher := &mockWatcher{resultChan: make(chan watch.Event, 1)}
+	watcher.resultChan <- watch.Event{Type: EventTypeModified, Object: &ready}
+	printer := &mockPrinter{}
+	ctrl := Controller{
+		Source:         &mockPodSource{listResult: &v1.PodList{Items: []v1.Pod{starting}}, watchResult: watcher},
+		CurrentPrinter: printer,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := ctrl.Run(ctx, RunOpts{Namespace: "default", Watch: true, Until: ScaleSettled(1)})
+	if err != nil || ctx.Err() != nil {
+		t.Fatalf("Controller.Run() = %v, ctx %v; want it to return once settled", err, ctx.Err())
+	}
+	if !printer.refreshCalled {
+		t.Error("the settled state was not drawn")
+	}
+}
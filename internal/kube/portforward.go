@@ -0,0 +1,350 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortPair is a LOCAL:REMOTE argument. Remote is a port number or name: a
+// service port for service targets, a container port otherwise. An empty
+// Local means the same number as the remote port, and "0" a random one.
+type PortPair struct {
+	Local  string
+	Remote string
+}
+
+func ParsePortPairs(args []string) ([]PortPair, error) {
+	pairs := make([]PortPair, 0, len(args))
+	for _, a := range args {
+		local, remote, ok := strings.Cut(a, ":")
+		if !ok {
+			local, remote = "", a
+		} else if local == "" {
+			local = "0"
+		}
+		if remote == "" {
+			return nil, fmt.Errorf("invalid port %q: missing the remote port", a)
+		}
+		if local != "" {
+			if n, err := strconv.ParseUint(local, 10, 16); err != nil || (n == 0 && local != "0") {
+				return nil, fmt.Errorf("invalid port %q: bad local port", a)
+			}
+		}
+		pairs = append(pairs, PortPair{Local: local, Remote: remote})
+	}
+	return pairs, nil
+}
+
+// forwardPorts turns the pairs into LOCAL:POD_PORT for one pod.
+func forwardPorts(svc *v1.Service, pod *v1.Pod, pairs []PortPair) ([]string, error) {
+	ports := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		port, err := podPort(svc, pod, p.Remote)
+		if err != nil {
+			return nil, err
+		}
+		local := p.Local
+		if local == "" {
+			local = p.Remote
+			if _, err := strconv.Atoi(local); err != nil {
+				local = strconv.Itoa(int(port))
+			}
+		}
+		ports = append(ports, local+":"+strconv.Itoa(int(port)))
+	}
+	return ports, nil
+}
+
+// podPort maps a remote port to a port of the pod, through the service's
+// targetPort when there is a service.
+func podPort(svc *v1.Service, pod *v1.Pod, remote string) (int32, error) {
+	target := intstr.Parse(remote)
+	if svc != nil {
+		found := false
+		for _, sp := range svc.Spec.Ports {
+			if strconv.Itoa(int(sp.Port)) == remote || (sp.Name != "" && sp.Name == remote) {
+				target, found = sp.TargetPort, true
+				if target.Type == intstr.Int && target.IntVal == 0 {
+					target = intstr.FromInt32(sp.Port)
+				}
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("service %s has no port %s", svc.Name, remote)
+		}
+	}
+	if target.Type == intstr.Int {
+		return target.IntVal, nil
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			if cp.Name == target.StrVal {
+				return cp.ContainerPort, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod %s has no container port named %q", pod.Name, target.StrVal)
+}
+
+// Forwarder forwards local ports to a pod until ctx is done or the
+// connection is lost. ready is called with the local ports once listening.
+type Forwarder interface {
+	Forward(ctx context.Context, ns, pod string, ports []string, ready func(local []uint16)) error
+}
+
+// RemoteForwarder tunnels SPDY over WebSocket, falling back to plain SPDY
+// for older apiservers.
+type RemoteForwarder struct {
+	Config    *rest.Config
+	Client    kubernetes.Interface
+	Addresses []string
+}
+
+func (f RemoteForwarder) Forward(ctx context.Context, ns, pod string, ports []string, ready func(local []uint16)) error {
+	u := f.Client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(ns).
+		Name(pod).
+		SubResource("portforward").
+		URL()
+
+	transport, upgrader, err := spdy.RoundTripperFor(f.Config)
+	if err != nil {
+		return err
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, u)
+	tunnel, err := portforward.NewSPDYOverWebsocketDialer(u, f.Config)
+	if err != nil {
+		return err
+	}
+	dialer = portforward.NewFallbackDialer(tunnel, dialer, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+
+	stop := make(chan struct{})
+	readyCh := make(chan struct{})
+	fw, err := portforward.NewOnAddresses(dialer, f.Addresses, ports, stop, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- fw.ForwardPorts() }()
+	select {
+	case <-readyCh:
+		forwarded, err := fw.GetPorts()
+		if err != nil {
+			close(stop)
+			return err
+		}
+		local := make([]uint16, len(forwarded))
+		for i, p := range forwarded {
+			local[i] = p.Local
+		}
+		ready(local)
+	case err := <-done:
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		close(stop)
+		return <-done
+	case err := <-done:
+		return err
+	}
+}
+
+// PortForward keeps ports forwarded to a pod of the target. When the pod is
+// deleted or the connection drops, it moves to another running pod of the
+// target, keeping the same local ports.
+type PortForward struct {
+	Resolver  PodResolver
+	Forwarder Forwarder
+	// Log receives connection and failover messages
+	Log io.Writer
+	// Retry is the wait before looking for another pod; 0 means 1s
+	Retry time.Duration
+	// Notify, when set, is told whenever forwarding starts or stops
+	Notify func(ForwardEvent)
+	// KeepTrying waits for a pod when the first attempt fails too, instead
+	// of returning its error
+	KeepTrying bool
+}
+
+// ForwardEvent reports that forwarding to Pod started on the Local ports,
+// or, with Err, that it stopped or that no pod could be found.
+type ForwardEvent struct {
+	Pod   string
+	Local []uint16
+	Err   error
+}
+
+func (f PortForward) notify(ev ForwardEvent) {
+	if f.Notify != nil {
+		f.Notify(ev)
+	}
+}
+
+type PortForwardOpts struct {
+	Namespace string
+	Target    string
+	Selector  string
+	Ports     []PortPair
+}
+
+func (f PortForward) Run(ctx context.Context, opts PortForwardOpts) error {
+	svc, err := f.service(ctx, opts.Namespace, opts.Target)
+	if err != nil {
+		return err
+	}
+	pairs := slices.Clone(opts.Ports)
+	retry := f.Retry
+	if retry <= 0 {
+		retry = time.Second
+	}
+
+	for first := true; ; first = false {
+		pod, err := f.pick(ctx, opts)
+		var ports []string
+		if err == nil {
+			ports, err = forwardPorts(svc, pod, pairs)
+		}
+		if err != nil {
+			if first && !f.KeepTrying {
+				return err
+			}
+			fmt.Fprintf(f.Log, "Waiting for a pod: %v\n", err)
+			f.notify(ForwardEvent{Err: err})
+		} else {
+			err = f.forward(ctx, pod, ports, func(local []uint16) {
+				// Keep the local ports, random ones included, on failover
+				for i := range pairs {
+					if i < len(local) {
+						pairs[i].Local = strconv.Itoa(int(local[i]))
+					}
+				}
+				for i := range min(len(ports), len(local)) {
+					_, remote, _ := strings.Cut(ports[i], ":")
+					fmt.Fprintf(f.Log, "Forwarding local port %d -> %s:%s\n", local[i], pod.Name, remote)
+				}
+				f.notify(ForwardEvent{Pod: pod.Name, Local: local})
+			})
+			if ctx.Err() != nil {
+				return nil
+			}
+			if first && !f.KeepTrying && !errors.Is(err, errPodGone) && !errors.Is(err, portforward.ErrLostConnectionToPod) {
+				return err
+			}
+			fmt.Fprintf(f.Log, "Lost pod %s: %v; reconnecting\n", pod.Name, err)
+			f.notify(ForwardEvent{Pod: pod.Name, Err: err})
+		}
+
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+var errPodGone = errors.New("pod is being deleted")
+
+// forward runs the forwarder until the pod goes away, as seen on a watch
+// of that pod.
+func (f PortForward) forward(ctx context.Context, pod *v1.Pod, ports []string, ready func([]uint16)) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	w, err := f.Resolver.Pods.Watch(ctx, pod.Namespace, ListOpts{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", pod.Name).String(),
+		ResourceVersion: pod.ResourceVersion,
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	go func() {
+		for ev := range w.ResultChan() {
+			p, ok := ev.Object.(*v1.Pod)
+			if !ok || p.Name != pod.Name || p.Namespace != pod.Namespace {
+				continue
+			}
+			if ev.Type == watch.Deleted || p.DeletionTimestamp != nil {
+				cancel(errPodGone)
+				return
+			}
+		}
+	}()
+
+	err = f.Forwarder.Forward(ctx, pod.Namespace, pod.Name, ports, ready)
+	if cause := context.Cause(ctx); errors.Is(cause, errPodGone) {
+		return cause
+	}
+	if err == nil && ctx.Err() == nil {
+		err = portforward.ErrLostConnectionToPod
+	}
+	return err
+}
+
+// pick returns the best running pod of the target.
+func (f PortForward) pick(ctx context.Context, opts PortForwardOpts) (*v1.Pod, error) {
+	pods, err := f.Resolver.Resolve(ctx, opts.Namespace, opts.Target, opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		if pods[i].Status.Phase == v1.PodRunning && pods[i].DeletionTimestamp == nil {
+			return &pods[i], nil
+		}
+	}
+	what := opts.Target
+	if what == "" {
+		what = "selector " + opts.Selector
+	}
+	return nil, fmt.Errorf("no running pod for %s yet", what)
+}
+
+// service returns the service when the target is one, else nil.
+func (f PortForward) service(ctx context.Context, ns, target string) (*v1.Service, error) {
+	resource, _, ok := strings.Cut(target, "/")
+	if !ok || f.Resolver.Mapper == nil {
+		return nil, nil
+	}
+	gvk, err := f.Resolver.Mapper.KindFor(schema.GroupVersionResource{Resource: resource})
+	if err != nil || gvk.GroupKind() != (schema.GroupKind{Kind: "Service"}) {
+		return nil, nil
+	}
+	obj, err := TreeBuilder{Dynamic: f.Resolver.Dynamic, Mapper: f.Resolver.Mapper}.getRef(ctx, ns, target)
+	if err != nil {
+		return nil, err
+	}
+	return toService(obj)
+}
+
+func toService(obj *unstructured.Unstructured) (*v1.Service, error) {
+	svc := &v1.Service{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, svc); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
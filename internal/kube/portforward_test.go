@@ -0,0 +1,132 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParsePortPairs(t *testing.T) {
+	pairs, err := ParsePortPairs([]string{"8080:80", "443", ":5432", "9000:http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(pairs); got != "[{8080 80} { 443} {0 5432} {9000 http}]" {
+		t.Errorf("ParsePortPairs() = %s", got)
+	}
+	for _, bad := range []string{"8080:", "x:80", "70000:80"} {
+		if _, err := ParsePortPairs([]string{bad}); err == nil {
+			t.Errorf("ParsePortPairs(%q) error = nil", bad)
+		}
+	}
+}
+
+func TestForwardPorts(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1"},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Ports: []v1.ContainerPort{{Name: "web", ContainerPort: 8080}},
+		}}},
+	}
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{
+			{Name: "http", Port: 80, TargetPort: intstr.FromString("web")},
+			{Name: "metrics", Port: 9090},
+			{Name: "admin", Port: 81, TargetPort: intstr.FromInt32(9000)},
+		}},
+	}
+
+	pairs, _ := ParsePortPairs([]string{"80", "8000:http", "metrics", "81"})
+	got, err := forwardPorts(svc, pod, pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, " ") != "80:8080 8000:8080 9090:9090 81:9000" {
+		t.Errorf("forwardPorts(service) = %v", got)
+	}
+
+	pairs, _ = ParsePortPairs([]string{"web", "3000:3000"})
+	if got, err = forwardPorts(nil, pod, pairs); err != nil || strings.Join(got, " ") != "8080:8080 3000:3000" {
+		t.Errorf("forwardPorts(pod) = %v, %v", got, err)
+	}
+
+	pairs, _ = ParsePortPairs([]string{"443"})
+	if _, err := forwardPorts(svc, pod, pairs); err == nil || !strings.Contains(err.Error(), "service web has no port 443") {
+		t.Errorf("forwardPorts() error = %v", err)
+	}
+}
+
+// forwardRecorder blocks like a real forwarder and records the pods it was
+// asked to forward to.
+type forwardRecorder struct {
+	mu    sync.Mutex
+	calls []string
+	pods  chan string
+}
+
+func (f *forwardRecorder) Forward(ctx context.Context, ns, pod string, ports []string, ready func([]uint16)) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, pod+" "+strings.Join(ports, ","))
+	f.mu.Unlock()
+	ready([]uint16{41234})
+	f.pods <- pod
+	<-ctx.Done()
+	return nil
+}
+
+func TestPortForward_Failover(t *testing.T) {
+	web1 := labeledPod("web-1", "web", true, time.Minute)
+	web2 := labeledPod("web-2", "web", true, time.Hour)
+	for _, p := range []*v1.Pod{&web1, &web2} {
+		p.Spec.Containers = []v1.Container{{Ports: []v1.ContainerPort{{Name: "web", ContainerPort: 8080}}}}
+	}
+	r := newTestResolver(web1, web2)
+	fwd := &forwardRecorder{pods: make(chan string, 2)}
+	var log bytes.Buffer
+	pf := PortForward{Resolver: r, Forwarder: fwd, Log: &log, Retry: time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ports, _ := ParsePortPairs([]string{":http"})
+	done := make(chan error, 1)
+	go func() {
+		done <- pf.Run(ctx, PortForwardOpts{Namespace: "default", Target: "services/web", Ports: ports})
+	}()
+
+	if pod := <-fwd.pods; pod != "web-1" {
+		t.Fatalf("forwarded to %s first, want the newest ready pod web-1", pod)
+	}
+	client := r.Pods.(ClientGoSource).Client
+	if err := client.CoreV1().Pods("default").Delete(ctx, "web-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case pod := <-fwd.pods:
+		if pod != "web-2" {
+			t.Fatalf("failed over to %s, want web-2", pod)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no failover after the pod was deleted")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+
+	// The random local port is kept across the failover
+	if strings.Join(fwd.calls, "; ") != "web-1 0:8080; web-2 41234:8080" {
+		t.Errorf("forward calls = %v", fwd.calls)
+	}
+	if !strings.Contains(log.String(), "Lost pod web-1: pod is being deleted") {
+		t.Errorf("log:\n%s", log.String())
+	}
+}
@@ -2,9 +2,13 @@ package kube
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"slices"
+	"strings"
 
 	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
 )
 
 type Printer interface {
@@ -12,8 +16,28 @@ type Printer interface {
 	Refresh([]PodRow) error // re-print (watch mode); can be same as Print
 }
 
+// DefaultPodColumns are the columns the pod tables show unless told otherwise.
+var DefaultPodColumns = []string{"NAME", "NAMESPACE", "READY", "STATUS", "RESTARTS", "AGE", "NODE"}
+
+// PodColumns are all the columns a pod table can show.
+var PodColumns = []string{"NAME", "NAMESPACE", "READY", "STATUS", "RESTARTS", "RECENT-RESTARTS", "AGE", "NODE", "OWNER", "LABELS"}
+
+// ParsePodColumns checks user-given column names, in any case.
+func ParsePodColumns(cols []string) ([]string, error) {
+	var out []string
+	for _, c := range cols {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if !slices.Contains(PodColumns, c) {
+			return nil, fmt.Errorf("unknown column %q (known: %s)", c, strings.Join(PodColumns, ", "))
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
 type TablePrinter struct {
-	Writer io.Writer
+	Writer  io.Writer
+	Columns []string
 }
 type JSONPrinter struct {
 	Writer io.Writer
@@ -35,13 +59,10 @@ func (p TablePrinter) Print(rows []PodRow) error   { return p.render(rows) }
 func (p TablePrinter) Refresh(rows []PodRow) error { return p.render(rows) }
 
 func (p TablePrinter) render(rows []PodRow) error {
-	table := tablewriter.NewWriter(p.Writer)
-	table.Header([]string{"NAME", "NAMESPACE", "READY", "STATUS", "RESTARTS", "AGE", "NODE"})
-	data := make([][]string, 0, len(rows))
-	for _, r := range rows {
-		data = append(data, []string{r.Name, r.Namespace, r.Ready, r.Status, r.Restarts, r.Age, r.Node})
-	}
-	table.Bulk(data)
+	cols := columnsOrDefault(p.Columns)
+	table := newTable(p.Writer)
+	table.Header(cols)
+	table.Bulk(podTableData(rows, cols))
 	table.Render()
 	return nil
 }
@@ -52,3 +73,54 @@ func (p JSONPrinter) Print(rows []PodRow) error {
 	return enc.Encode(rows)
 }
 func (p JSONPrinter) Refresh(rows []PodRow) error { return p.Print(rows) }
+
+// newTable returns a table that keeps header names as given; the default
+// auto-format turns "UP-TO-DATE" into "UP - TO - DATE".
+func newTable(w io.Writer) *tablewriter.Table {
+	return tablewriter.NewTable(w, tablewriter.WithHeaderAutoFormat(tw.Off))
+}
+
+func columnsOrDefault(cols []string) []string {
+	if len(cols) == 0 {
+		return DefaultPodColumns
+	}
+	return cols
+}
+
+func podTableData(rows []PodRow, cols []string) [][]string {
+	data := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = podCell(r, c)
+		}
+		data = append(data, cells)
+	}
+	return data
+}
+
+func podCell(r PodRow, col string) string {
+	switch col {
+	case "NAME":
+		return r.Name
+	case "NAMESPACE":
+		return r.Namespace
+	case "READY":
+		return r.Ready
+	case "STATUS":
+		return r.Status
+	case "RESTARTS":
+		return r.Restarts
+	case "RECENT-RESTARTS":
+		return r.RecentRestarts
+	case "AGE":
+		return r.Age
+	case "NODE":
+		return r.Node
+	case "OWNER":
+		return r.Owner
+	case "LABELS":
+		return r.Labels
+	}
+	return ""
+}
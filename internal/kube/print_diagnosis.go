@@ -0,0 +1,48 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type DiagnosisPrinter interface {
+	Print(Diagnosis) error
+}
+
+type DiagnosisTextPrinter struct {
+	Writer io.Writer
+}
+
+func NewDiagnosisTextPrinter(writer io.Writer) DiagnosisTextPrinter {
+	return DiagnosisTextPrinter{Writer: writer}
+}
+
+func (p DiagnosisTextPrinter) Print(d Diagnosis) error {
+	fmt.Fprintf(p.Writer, "Pod %s/%s is %s\n", d.Namespace, d.Name, d.Status)
+	for i, f := range d.Findings {
+		fmt.Fprintf(p.Writer, "\n%d. [%s] %s\n", i+1, f.Severity, f.Reason)
+		fmt.Fprintf(p.Writer, "   %s\n", f.Summary)
+		if len(f.Evidence) > 0 {
+			fmt.Fprintln(p.Writer, "   Evidence:")
+			for _, e := range f.Evidence {
+				fmt.Fprintf(p.Writer, "     - %s\n", e)
+			}
+		}
+	}
+	return nil
+}
+
+type DiagnosisJSONPrinter struct {
+	Writer io.Writer
+}
+
+func NewDiagnosisJSONPrinter(writer io.Writer) DiagnosisJSONPrinter {
+	return DiagnosisJSONPrinter{Writer: writer}
+}
+
+func (p DiagnosisJSONPrinter) Print(d Diagnosis) error {
+	enc := json.NewEncoder(p.Writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,116 @@
+package kube
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RowChange describes how a pod row differs between two snapshots.
+type RowChange struct {
+	Type   string // EventTypeAdded, EventTypeModified or EventTypeDeleted
+	Row    PodRow
+	Fields []FieldChange
+}
+
+type FieldChange struct {
+	Column string
+	Old    string
+	New    string
+}
+
+func rowKey(r PodRow) string { return r.Namespace + "/" + r.Name }
+
+// DiffRows compares two snapshots column by column. AGE is ignored since it
+// changes on its own as time passes.
+func DiffRows(prev, cur []PodRow, cols []string) []RowChange {
+	before := make(map[string]PodRow, len(prev))
+	for _, r := range prev {
+		before[rowKey(r)] = r
+	}
+
+	var changes []RowChange
+	seen := make(map[string]bool, len(cur))
+	for _, r := range cur {
+		key := rowKey(r)
+		seen[key] = true
+		old, ok := before[key]
+		if !ok {
+			changes = append(changes, RowChange{Type: EventTypeAdded, Row: r})
+			continue
+		}
+		var fields []FieldChange
+		for _, c := range cols {
+			if c == "AGE" {
+				continue
+			}
+			if o, n := podCell(old, c), podCell(r, c); o != n {
+				fields = append(fields, FieldChange{Column: c, Old: o, New: n})
+			}
+		}
+		if len(fields) > 0 {
+			changes = append(changes, RowChange{Type: EventTypeModified, Row: r, Fields: fields})
+		}
+	}
+	var deleted []RowChange
+	for _, r := range prev {
+		if !seen[rowKey(r)] {
+			deleted = append(deleted, RowChange{Type: EventTypeDeleted, Row: r})
+		}
+	}
+	slices.SortFunc(deleted, func(a, b RowChange) int { return strings.Compare(rowKey(a.Row), rowKey(b.Row)) })
+	return append(changes, deleted...)
+}
+
+// DiffPrinter prints the first snapshot as a table and afterwards only the
+// field-level changes between snapshots, one line per pod. Meant for watch
+// mode when the output is not a terminal.
+type DiffPrinter struct {
+	Writer  io.Writer
+	Columns []string
+	prev    []PodRow
+	now     func() time.Time
+}
+
+func NewDiffPrinter(writer io.Writer) *DiffPrinter {
+	return &DiffPrinter{Writer: writer, now: time.Now}
+}
+
+func (p *DiffPrinter) Print(rows []PodRow) error {
+	p.prev = rows
+	return TablePrinter{Writer: p.Writer, Columns: p.Columns}.Print(rows)
+}
+
+func (p *DiffPrinter) Refresh(rows []PodRow) error {
+	cols := columnsOrDefault(p.Columns)
+	ts := p.now().Format("15:04:05")
+	for _, c := range DiffRows(p.prev, rows, cols) {
+		var line string
+		switch c.Type {
+		case EventTypeAdded:
+			var cells []string
+			for _, col := range cols {
+				if col == "NAME" || col == "NAMESPACE" {
+					continue
+				}
+				cells = append(cells, col+"="+podCell(c.Row, col))
+			}
+			line = fmt.Sprintf("%s + %s %s", ts, rowKey(c.Row), strings.Join(cells, " "))
+		case EventTypeModified:
+			var fields []string
+			for _, f := range c.Fields {
+				fields = append(fields, fmt.Sprintf("%s: %s -> %s", f.Column, f.Old, f.New))
+			}
+			line = fmt.Sprintf("%s ~ %s %s", ts, rowKey(c.Row), strings.Join(fields, ", "))
+		case EventTypeDeleted:
+			line = fmt.Sprintf("%s - %s", ts, rowKey(c.Row))
+		}
+		if _, err := fmt.Fprintln(p.Writer, line); err != nil {
+			return err
+		}
+	}
+	p.prev = rows
+	return nil
+}
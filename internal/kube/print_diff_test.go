@@ -0,0 +1,132 @@
+package kube
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffRows(t *testing.T) {
+	prev := []PodRow{
+		{Name: "api", Namespace: "default", Ready: "0/1", Status: "Pending", Age: "1m"},
+		{Name: "db", Namespace: "default", Ready: "1/1", Status: "Running", Age: "5m"},
+	}
+	cur := []PodRow{
+		{Name: "api", Namespace: "default", Ready: "1/1", Status: "Running", Age: "2m"},
+		{Name: "web", Namespace: "default", Ready: "0/1", Status: "Pending", Age: "0s"},
+	}
+
+	changes := DiffRows(prev, cur, DefaultPodColumns)
+	if len(changes) != 3 {
+		t.Fatalf("DiffRows() returned %d changes, want 3: %+v", len(changes), changes)
+	}
+	if c := changes[0]; c.Type != EventTypeModified || c.Row.Name != "api" || len(c.Fields) != 2 {
+		t.Errorf("changes[0] = %+v, want api modified in READY and STATUS", c)
+	}
+	if f := changes[0].Fields[1]; f.Column != "STATUS" || f.Old != "Pending" || f.New != "Running" {
+		t.Errorf("changes[0].Fields[1] = %+v", f)
+	}
+	if c := changes[1]; c.Type != EventTypeAdded || c.Row.Name != "web" {
+		t.Errorf("changes[1] = %+v, want web added", c)
+	}
+	if c := changes[2]; c.Type != EventTypeDeleted || c.Row.Name != "db" {
+		t.Errorf("changes[2] = %+v, want db deleted", c)
+	}
+}
+
+func TestDiffPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewDiffPrinter(&buf)
+	p.Columns = []string{"NAME", "NAMESPACE", "STATUS"}
+	p.now = func() time.Time { return time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC) }
+
+	p.Print([]PodRow{{Name: "api", Namespace: "default", Status: "Pending"}})
+	buf.Reset()
+	if err := p.Refresh([]PodRow{
+		{Name: "api", Namespace: "default", Status: "Running"},
+		{Name: "web", Namespace: "default", Status: "Pending"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	p.Refresh([]PodRow{{Name: "web", Namespace: "default", Status: "Pending"}})
+
+	want := []string{
+		"12:30:00 ~ default/api STATUS: Pending -> Running",
+		"12:30:00 + default/web STATUS=Pending",
+		"12:30:00 - default/api",
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DiffPrinter output =\n%s\nwant\n%s", buf.String(), strings.Join(want, "\n"))
+	}
+}
+
+func TestLiveTablePrinter_Highlight(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := NewLiveTablePrinter(&buf)
+	p.Columns = []string{"NAME", "STATUS"}
+	p.FadePeriod = time.Hour // keep the fade timer from firing during the test
+	p.now = func() time.Time { return now }
+	p.tty = true
+
+	p.Print([]PodRow{
+		{Name: "api", Namespace: "default", Status: "Pending"},
+		{Name: "db", Namespace: "default", Status: "Running"},
+	})
+	if strings.Contains(buf.String(), ansiGreen) {
+		t.Errorf("first frame should not be highlighted:\n%q", buf.String())
+	}
+
+	buf.Reset()
+	p.Refresh([]PodRow{
+		{Name: "api", Namespace: "default", Status: "Running"},
+		{Name: "web", Namespace: "default", Status: "Pending"},
+	})
+	out := buf.String()
+	for _, want := range []string{
+		ansiBold + ansiYellow + "Running" + ansiReset,
+		ansiGreen + "web" + ansiReset,
+		ansiRed + "db" + ansiReset,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Refresh() output missing %q:\n%q", want, out)
+		}
+	}
+
+	// Once the fade period is over the deleted row disappears
+	now = now.Add(2 * time.Hour)
+	buf.Reset()
+	p.Refresh([]PodRow{
+		{Name: "api", Namespace: "default", Status: "Running"},
+		{Name: "web", Namespace: "default", Status: "Pending"},
+	})
+	if out := buf.String(); strings.Contains(out, "db") || strings.Contains(out, ansiGreen) {
+		t.Errorf("highlights should have faded:\n%q", out)
+	}
+	if p.timer != nil {
+		t.Error("fade timer still scheduled with no highlights left")
+	}
+}
+
+func TestLiveTablePrinter_Stop(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewLiveTablePrinter(&buf)
+	p.Columns = []string{"NAME"}
+	p.FadePeriod = 10 * time.Millisecond
+	p.tty = true
+
+	p.Print([]PodRow{{Name: "c"}, {Name: "b"}, {Name: "a"}})
+	p.Refresh(nil)
+	out := buf.String()
+	if a, b, c := strings.LastIndex(out, "a"), strings.LastIndex(out, "b"), strings.LastIndex(out, "c"); !(a < b && b < c) {
+		t.Errorf("deleted rows not sorted:\n%q", out)
+	}
+
+	p.Stop()
+	buf.Reset()
+	time.Sleep(5 * p.FadePeriod)
+	if buf.Len() != 0 {
+		t.Errorf("repainted after Stop:\n%q", buf.String())
+	}
+}
@@ -0,0 +1,84 @@
+package kube
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+type ForwardStatusPrinter interface {
+	Print([]ForwardStatus) error
+}
+
+// ForwardTablePrinter repaints the status table in place on a terminal.
+// Otherwise it prints the table again only when a forward changes state or
+// pod, since byte counts alone would flood a log.
+type ForwardTablePrinter struct {
+	out   io.Writer
+	tty   bool
+	lines int
+	prev  []ForwardStatus
+}
+
+func NewForwardTablePrinter(writer io.Writer) *ForwardTablePrinter {
+	_, tty := terminalFd(writer)
+	return &ForwardTablePrinter{out: writer, tty: tty}
+}
+
+func (p *ForwardTablePrinter) Print(rows []ForwardStatus) error {
+	if !p.tty && p.prev != nil && !forwardStateChanged(p.prev, rows) {
+		return nil
+	}
+	first := p.prev == nil
+	p.prev = slices.Clone(rows)
+
+	buf := &bytes.Buffer{}
+	table := newTable(buf)
+	table.Header([]string{"NAME", "POD", "PORTS", "STATUS", "IN", "OUT", "LAST ERROR"})
+	data := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		state := string(r.State)
+		if p.tty {
+			switch r.State {
+			case ForwardConnected:
+				state = ansiGreen + state + ansiReset
+			case ForwardReconnecting:
+				state = ansiYellow + state + ansiReset
+			case ForwardFailed:
+				state = ansiRed + state + ansiReset
+			}
+		}
+		data = append(data, []string{r.Name, r.Pod, r.Ports, state, formatMemory(r.BytesIn), formatMemory(r.BytesOut), r.LastError})
+	}
+	table.Bulk(data)
+	table.Render()
+	frame := buf.String()
+
+	if !p.tty {
+		if !first {
+			fmt.Fprintln(p.out)
+		}
+		_, err := fmt.Fprint(p.out, frame)
+		return err
+	}
+	if p.lines > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA\r\x1b[J", p.lines)
+	}
+	p.lines = strings.Count(strings.TrimRight(frame, "\n"), "\n") + 1
+	_, err := fmt.Fprint(p.out, frame)
+	return err
+}
+
+func forwardStateChanged(prev, rows []ForwardStatus) bool {
+	if len(prev) != len(rows) {
+		return true
+	}
+	for i := range rows {
+		if prev[i].State != rows[i].State || prev[i].Pod != rows[i].Pod || prev[i].LastError != rows[i].LastError {
+			return true
+		}
+	}
+	return false
+}
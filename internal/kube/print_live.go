@@ -5,43 +5,143 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"sync"
+	"time"
+)
 
-	"github.com/olekukonko/tablewriter"
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
 )
 
+// DefaultFadePeriod is how long added, modified and deleted rows stay
+// highlighted in the live table.
+const DefaultFadePeriod = 3 * time.Second
+
 type LiveTablePrinter struct {
+	Columns []string
+	// Highlight colours added (green), modified (yellow) and deleted (red)
+	// rows for FadePeriod after they change
+	Highlight  bool
+	FadePeriod time.Duration
+
 	out      io.Writer
 	mu       sync.Mutex
 	lines    int
 	rendered bool
+
+	// tty is false when out is not a terminal; frames are then appended
+	// without escape codes. size reports the terminal width and height.
+	tty  bool
+	size func() (width, height int)
+
+	prev    []PodRow
+	marks   map[string]rowMark
+	timer   *time.Timer
+	stopped bool
+	now     func() time.Time
+}
+
+// Stopper is implemented by printers that repaint on their own; Stop ends
+// that once nothing else will be printed.
+type Stopper interface {
+	Stop()
+}
+
+type rowMark struct {
+	change RowChange
+	at     time.Time
 }
 
 func NewLiveTablePrinter(writer io.Writer) *LiveTablePrinter {
-	return &LiveTablePrinter{out: writer}
+	p := &LiveTablePrinter{
+		Highlight:  true,
+		FadePeriod: DefaultFadePeriod,
+		out:        writer,
+		marks:      map[string]rowMark{},
+		now:        time.Now,
+		size:       func() (int, int) { return 0, 0 },
+	}
+	if fd, ok := terminalFd(writer); ok {
+		p.tty = true
+		p.size = func() (int, int) { return terminalSize(fd) }
+	}
+	return p
 }
 
 func (t *LiveTablePrinter) Print(rows []PodRow) error   { return t.render(rows, false) }
 func (t *LiveTablePrinter) Refresh(rows []PodRow) error { return t.render(rows, true) }
 
+// Stop cancels the pending fade repaint, leaving the last frame as is.
+func (t *LiveTablePrinter) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
 func (t *LiveTablePrinter) render(rows []PodRow, inplace bool) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	return t.renderLocked(rows, inplace)
+}
+
+func (t *LiveTablePrinter) renderLocked(rows []PodRow, inplace bool) error {
+	cols := columnsOrDefault(t.Columns)
+	highlight := t.Highlight && t.tty
+	var gone []PodRow
+	if highlight {
+		gone = t.track(rows, cols)
+	}
+	all := append(slices.Clone(rows), gone...)
+	t.prev = rows
+	if highlight {
+		t.scheduleFade()
+	}
+
+	data := podTableData(all, cols)
+	var footer string
+	if t.tty {
+		width, height := t.size()
+		fitColumns(cols, data, width)
+		// Leave room for the borders, the header, the overflow line and the cursor
+		if limit := height - 6; height > 0 && len(data) > max(limit, 1) {
+			limit = max(limit, 1)
+			footer = fmt.Sprintf("... %d more pods not shown, enlarge the terminal or narrow the selection\n", len(data)-limit)
+			data, all = data[:limit], all[:limit]
+		}
+	}
+	if highlight {
+		t.colorize(all, data, cols)
+	}
 
 	// Render the table to a buffer
 	buf := &bytes.Buffer{}
-	tw := tablewriter.NewWriter(buf)
-	tw.Header([]string{"NAME", "NAMESPACE", "READY", "STATUS", "RESTARTS", "AGE", "NODE"})
+	table := newTable(buf)
+	table.Header(cols)
+	table.Bulk(data)
+	table.Render()
+	buf.WriteString(footer)
 
-	data := make([][]string, 0, len(rows))
-	for _, r := range rows {
-		data = append(data, []string{r.Name, r.Namespace, r.Ready, r.Status, r.Restarts, r.Age, r.Node})
+	frame := buf.String()
+	if !t.tty {
+		// Not a terminal: append every snapshot, separated by a blank line
+		if t.rendered {
+			fmt.Fprintln(t.out)
+		}
+		fmt.Fprint(t.out, frame)
+		t.rendered = true
+		return nil
 	}
-	tw.Bulk(data)
-	tw.Render()
 
-	frame := buf.String()
 	// Count lines to know how far to move the cursor up next time.
 	// Trim trailing newline so the count matches visible lines.
 	trimmed := strings.TrimRight(frame, "\n")
@@ -63,3 +163,99 @@ func (t *LiveTablePrinter) render(rows []PodRow, inplace bool) error {
 	t.rendered = true
 	return nil
 }
+
+// track records the changes since the previous frame and drops the ones
+// older than the fade period. It returns the rows deleted recently so they
+// can fade out at the bottom of the table.
+func (t *LiveTablePrinter) track(rows []PodRow, cols []string) []PodRow {
+	now := t.now()
+	if t.rendered {
+		for _, c := range DiffRows(t.prev, rows, cols) {
+			t.marks[rowKey(c.Row)] = rowMark{change: c, at: now}
+		}
+	}
+
+	for key, m := range t.marks {
+		if now.Sub(m.at) >= t.FadePeriod {
+			delete(t.marks, key)
+		}
+	}
+
+	var gone []PodRow
+	for _, r := range rows {
+		if m, ok := t.marks[rowKey(r)]; ok && m.change.Type == EventTypeDeleted {
+			// Re-added before the deleted row faded out
+			delete(t.marks, rowKey(r))
+		}
+	}
+	for _, m := range t.marks {
+		if m.change.Type == EventTypeDeleted {
+			gone = append(gone, m.change.Row)
+		}
+	}
+	slices.SortFunc(gone, func(a, b PodRow) int { return strings.Compare(rowKey(a), rowKey(b)) })
+	return gone
+}
+
+// colorize paints added rows green, modified rows yellow with the changed
+// cells in bold and deleted rows red.
+func (t *LiveTablePrinter) colorize(rows []PodRow, data [][]string, cols []string) {
+	for i, r := range rows {
+		m, ok := t.marks[rowKey(r)]
+		if !ok {
+			continue
+		}
+		switch m.change.Type {
+		case EventTypeAdded:
+			colorCells(data[i], ansiGreen)
+		case EventTypeDeleted:
+			colorCells(data[i], ansiRed)
+		case EventTypeModified:
+			changed := map[string]bool{}
+			for _, f := range m.change.Fields {
+				changed[f.Column] = true
+			}
+			for j, c := range cols {
+				if changed[c] {
+					data[i][j] = ansiBold + ansiYellow + data[i][j] + ansiReset
+				} else {
+					data[i][j] = ansiYellow + data[i][j] + ansiReset
+				}
+			}
+		}
+	}
+}
+
+// scheduleFade repaints once the oldest highlight expires so faded rows
+// disappear even when no new events arrive.
+func (t *LiveTablePrinter) scheduleFade() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if len(t.marks) == 0 || t.stopped {
+		return
+	}
+	next := t.FadePeriod
+	now := t.now()
+	for _, m := range t.marks {
+		if d := t.FadePeriod - now.Sub(m.at); d < next {
+			next = d
+		}
+	}
+	t.timer = time.AfterFunc(next, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		// Fired while Stop was waiting for the lock
+		if t.stopped {
+			return
+		}
+		t.renderLocked(t.prev, true)
+	})
+}
+
+func colorCells(cells []string, color string) {
+	for i, c := range cells {
+		cells[i] = color + c + ansiReset
+	}
+}
@@ -0,0 +1,99 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+type StatusPrinter interface {
+	Print(ClusterStatus) error
+}
+
+type StatusTextPrinter struct {
+	Writer io.Writer
+}
+
+func NewStatusTextPrinter(writer io.Writer) StatusTextPrinter {
+	return StatusTextPrinter{Writer: writer}
+}
+
+func (p StatusTextPrinter) Print(st ClusterStatus) error {
+	table := tablewriter.NewWriter(p.Writer)
+	table.Header([]string{"NAMESPACE", "PODS", "NOT READY", "RECENT RESTARTS", "STATUS"})
+	data := make([][]string, 0, len(st.Namespaces))
+	for _, ns := range st.Namespaces {
+		data = append(data, []string{
+			ns.Namespace,
+			fmt.Sprintf("%d", ns.Pods),
+			fmt.Sprintf("%d", ns.NotReady),
+			fmt.Sprintf("%d", ns.RecentRestarts),
+			formatStatusCounts(ns.ByStatus),
+		})
+	}
+	table.Bulk(data)
+	table.Render()
+
+	fmt.Fprintf(p.Writer, "\nNodes: %d/%d ready\n", st.Nodes.Ready, st.Nodes.Total)
+	for _, n := range st.Nodes.Problems {
+		fmt.Fprintf(p.Writer, "  %s: %s\n", n.Name, strings.Join(n.Conditions, ", "))
+	}
+
+	if st.MetricsError != "" {
+		fmt.Fprintf(p.Writer, "\nTop consumers unavailable: %s\n", st.MetricsError)
+		return nil
+	}
+	if len(st.TopCPU) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(p.Writer)
+	top := tablewriter.NewWriter(p.Writer)
+	top.Header([]string{"TOP CPU", "CPU", "TOP MEMORY", "MEMORY"})
+	data = data[:0]
+	for i := range st.TopCPU {
+		c, m := st.TopCPU[i], st.TopMemory[i]
+		data = append(data, []string{c.Namespace + "/" + c.Name, c.CPU, m.Namespace + "/" + m.Name, m.Memory})
+	}
+	top.Bulk(data)
+	top.Render()
+	return nil
+}
+
+// formatStatusCounts renders e.g. "Running:10 CrashLoopBackOff:2", most
+// common status first.
+func formatStatusCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s:%d", k, counts[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+type StatusJSONPrinter struct {
+	Writer io.Writer
+}
+
+func NewStatusJSONPrinter(writer io.Writer) StatusJSONPrinter {
+	return StatusJSONPrinter{Writer: writer}
+}
+
+func (p StatusJSONPrinter) Print(st ClusterStatus) error {
+	enc := json.NewEncoder(p.Writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(st)
+}
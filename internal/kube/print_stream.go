@@ -0,0 +1,88 @@
+package kube
+
+import (
+	"io"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// PagePrinter is implemented by printers that can show a list while its
+// pages arrive instead of waiting for all of them.
+type PagePrinter interface {
+	PrintPage([]PodRow) error
+	// Close ends the output once the last page was printed
+	Close() error
+}
+
+// StreamingTablePrinter prints a pod table page by page. Column widths are
+// fixed by the first page; longer cells in later pages are truncated.
+type StreamingTablePrinter struct {
+	Writer  io.Writer
+	Columns []string
+
+	table  *tablewriter.Table
+	widths []int
+}
+
+func NewStreamingTablePrinter(writer io.Writer) *StreamingTablePrinter {
+	return &StreamingTablePrinter{Writer: writer}
+}
+
+func (p *StreamingTablePrinter) Print(rows []PodRow) error {
+	return TablePrinter{Writer: p.Writer, Columns: p.Columns}.Print(rows)
+}
+
+func (p *StreamingTablePrinter) Refresh(rows []PodRow) error { return p.Print(rows) }
+
+func (p *StreamingTablePrinter) PrintPage(rows []PodRow) error {
+	cols := columnsOrDefault(p.Columns)
+	data := podTableData(rows, cols)
+	if p.table == nil {
+		if err := p.start(cols, data); err != nil {
+			return err
+		}
+	}
+	for _, cells := range data {
+		for i, c := range cells {
+			cells[i] = truncate(c, p.widths[i], cols[i] == "NAME")
+		}
+		if err := p.table.Append(cells); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *StreamingTablePrinter) Close() error {
+	if p.table == nil {
+		// No pages at all; still print the header
+		if err := p.start(columnsOrDefault(p.Columns), nil); err != nil {
+			return err
+		}
+	}
+	return p.table.Close()
+}
+
+func (p *StreamingTablePrinter) start(cols []string, first [][]string) error {
+	p.widths = make([]int, len(cols))
+	widths := tw.NewMapper[int, int]()
+	for i, c := range cols {
+		p.widths[i] = len([]rune(c))
+		for _, cells := range first {
+			p.widths[i] = max(p.widths[i], len([]rune(cells[i])))
+		}
+		// Plus the padding on both sides
+		widths.Set(i, p.widths[i]+2)
+	}
+	p.table = tablewriter.NewTable(p.Writer,
+		tablewriter.WithHeaderAutoFormat(tw.Off),
+		tablewriter.WithStreaming(tw.StreamConfig{Enable: true}),
+		tablewriter.WithColumnWidths(widths),
+	)
+	if err := p.table.Start(); err != nil {
+		return err
+	}
+	p.table.Header(cols)
+	return nil
+}
@@ -0,0 +1,60 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+type TreePrinter interface {
+	Print([]*TreeNode) error
+}
+
+type TreeTextPrinter struct {
+	Writer io.Writer
+}
+
+func NewTreeTextPrinter(writer io.Writer) TreeTextPrinter {
+	return TreeTextPrinter{Writer: writer}
+}
+
+func (p TreeTextPrinter) Print(roots []*TreeNode) error {
+	// The tree prefixes start with spaces, which the table renderer trims,
+	// so align the columns with a tabwriter instead.
+	tw := tabwriter.NewWriter(p.Writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tNAME\tREADY\tSTATUS\tAGE\tNOTE")
+	for _, n := range roots {
+		p.printNode(tw, n, "", "")
+	}
+	return tw.Flush()
+}
+
+func (p TreeTextPrinter) printNode(w io.Writer, n *TreeNode, prefix, childPrefix string) {
+	name := n.Kind
+	if n.Name != "" {
+		name += "/" + n.Name
+	}
+	fmt.Fprintf(w, "%s\t%s%s\t%s\t%s\t%s\t%s\n", n.Namespace, prefix, name, n.Ready, n.Status, n.Age, n.Note)
+	for i, c := range n.Children {
+		if i == len(n.Children)-1 {
+			p.printNode(w, c, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			p.printNode(w, c, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+type TreeJSONPrinter struct {
+	Writer io.Writer
+}
+
+func NewTreeJSONPrinter(writer io.Writer) TreeJSONPrinter {
+	return TreeJSONPrinter{Writer: writer}
+}
+
+func (p TreeJSONPrinter) Print(roots []*TreeNode) error {
+	enc := json.NewEncoder(p.Writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(roots)
+}
@@ -0,0 +1,50 @@
+package kube
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type WorkloadPrinter interface {
+	Print([]WorkloadRow) error
+	Refresh([]WorkloadRow) error
+}
+
+type WorkloadTablePrinter struct {
+	Writer io.Writer
+}
+
+func NewWorkloadTablePrinter(writer io.Writer) WorkloadTablePrinter {
+	return WorkloadTablePrinter{Writer: writer}
+}
+
+func (p WorkloadTablePrinter) Print(rows []WorkloadRow) error   { return p.render(rows) }
+func (p WorkloadTablePrinter) Refresh(rows []WorkloadRow) error { return p.render(rows) }
+
+func (p WorkloadTablePrinter) render(rows []WorkloadRow) error {
+	table := newTable(p.Writer)
+	table.Header([]string{"NAME", "NAMESPACE", "DESIRED", "CURRENT", "READY", "UP-TO-DATE", "AVAILABLE", "ROLLOUT", "AGE"})
+	data := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		data = append(data, []string{r.Name, r.Namespace, r.Desired, r.Current, r.Ready, r.UpToDate, r.Available, r.Rollout, r.Age})
+	}
+	table.Bulk(data)
+	table.Render()
+	return nil
+}
+
+type WorkloadJSONPrinter struct {
+	Writer io.Writer
+}
+
+func NewWorkloadJSONPrinter(writer io.Writer) WorkloadJSONPrinter {
+	return WorkloadJSONPrinter{Writer: writer}
+}
+
+func (p WorkloadJSONPrinter) Print(rows []WorkloadRow) error {
+	enc := json.NewEncoder(p.Writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+func (p WorkloadJSONPrinter) Refresh(rows []WorkloadRow) error { return p.Print(rows) }
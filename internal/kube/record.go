@@ -0,0 +1,128 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Record types besides the watch event types.
+const (
+	RecordList    = "LIST"
+	RecordMetrics = "METRICS"
+)
+
+// RecordEntry is one line of a recording: the initial pod list, a watch
+// event, or a frame of pod metrics.
+type RecordEntry struct {
+	Time   time.Time       `json:"time"`
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// Recorder writes a session as JSON lines. It is safe for concurrent use.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+	now func() time.Time
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w), now: time.Now}
+}
+
+func (r *Recorder) Record(typ string, obj any) error {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(RecordEntry{Time: r.now(), Type: typ, Object: raw}); err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// Err returns the first write error, including the ones from watch events
+// which cannot be reported as they pass through.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// RecordingSource is a PodSource that records the list and every watch
+// event of Source.
+type RecordingSource struct {
+	Source   PodSource
+	Recorder *Recorder
+}
+
+func (s RecordingSource) List(ctx context.Context, ns string, opts ListOpts) (*v1.PodList, error) {
+	list, err := s.Source.List(ctx, ns, opts)
+	if err != nil {
+		return nil, err
+	}
+	return list, s.Recorder.Record(RecordList, list)
+}
+
+// getByName records the pods fetched by name as one list, however many
+// requests the wrapped source needed, so that a replay starts from all of
+// them.
+func (s RecordingSource) getByName(ctx context.Context, ns string, opts ListOpts, names []string) (*v1.PodList, error) {
+	list, err := getByName(ctx, s.Source, ns, opts, names)
+	if list == nil {
+		return nil, err
+	}
+	if rerr := s.Recorder.Record(RecordList, list); rerr != nil {
+		return nil, rerr
+	}
+	return list, err
+}
+
+func (s RecordingSource) Watch(ctx context.Context, ns string, opts ListOpts) (watch.Interface, error) {
+	w, err := s.Source.Watch(ctx, ns, opts)
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(ev watch.Event) (watch.Event, bool) {
+		if pod, ok := ev.Object.(*v1.Pod); ok {
+			s.Recorder.Record(string(ev.Type), pod)
+		}
+		return ev, true
+	}), nil
+}
+
+// RecordingUsageSource records every metrics frame of Source.
+type RecordingUsageSource struct {
+	Source   UsageSource
+	Recorder *Recorder
+}
+
+func (s RecordingUsageSource) PodUsage(ctx context.Context, ns string) ([]PodMetricsRow, error) {
+	rows, err := s.Source.PodUsage(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]usageRecord, len(rows))
+	for i, r := range rows {
+		records[i] = usageRecord{PodMetricsRow: r, CPUMillis: r.CPUMillis, MemoryBytes: r.MemoryBytes}
+	}
+	return rows, s.Recorder.Record(RecordMetrics, records)
+}
+
+// usageRecord is a recorded metrics row. It keeps the raw usage, which
+// PodMetricsRow leaves out of its JSON, so that replays sort and filter
+// on it.
+type usageRecord struct {
+	PodMetricsRow
+	CPUMillis   int64 `json:"cpuMillis"`
+	MemoryBytes int64 `json:"memoryBytes"`
+}
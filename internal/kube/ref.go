@@ -0,0 +1,70 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	KindPod         = "Pod"
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+)
+
+// ObjectRef points at a single named object, e.g. "deploy/api".
+type ObjectRef struct {
+	Kind string
+	Name string
+}
+
+func (r ObjectRef) String() string {
+	return strings.ToLower(r.Kind) + "/" + r.Name
+}
+
+var kindAliases = map[string]string{
+	"po":           KindPod,
+	"pod":          KindPod,
+	"pods":         KindPod,
+	"deploy":       KindDeployment,
+	"deployment":   KindDeployment,
+	"deployments":  KindDeployment,
+	"sts":          KindStatefulSet,
+	"statefulset":  KindStatefulSet,
+	"statefulsets": KindStatefulSet,
+	"ds":           KindDaemonSet,
+	"daemonset":    KindDaemonSet,
+	"daemonsets":   KindDaemonSet,
+}
+
+// NormalizeKind maps a user supplied resource name or alias to its Kind.
+func NormalizeKind(s string) (string, error) {
+	if k, ok := kindAliases[strings.ToLower(s)]; ok {
+		return k, nil
+	}
+	return "", fmt.Errorf("unsupported resource type %q", s)
+}
+
+// ParseObjectRef accepts either "kind/name" or the two separate args "kind name".
+func ParseObjectRef(args []string) (ObjectRef, error) {
+	switch len(args) {
+	case 1:
+		kind, name, ok := strings.Cut(args[0], "/")
+		if !ok || name == "" {
+			return ObjectRef{}, fmt.Errorf("expected TYPE/NAME, got %q", args[0])
+		}
+		k, err := NormalizeKind(kind)
+		if err != nil {
+			return ObjectRef{}, err
+		}
+		return ObjectRef{Kind: k, Name: name}, nil
+	case 2:
+		k, err := NormalizeKind(args[0])
+		if err != nil {
+			return ObjectRef{}, err
+		}
+		return ObjectRef{Kind: k, Name: args[1]}, nil
+	default:
+		return ObjectRef{}, fmt.Errorf("expected TYPE/NAME or TYPE NAME")
+	}
+}
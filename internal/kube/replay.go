@@ -0,0 +1,202 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ReplaySource plays back a recording made with RecordingSource or
+// RecordingUsageSource. It is a PodSource (and a UsageSource) so the same
+// controllers and printers used against a cluster render the replay.
+type ReplaySource struct {
+	// Speed scales the delays between entries: 2 plays twice as fast, 0
+	// plays without delays
+	Speed float64
+	// Since seeks: entries before it are applied without delay
+	Since time.Time
+
+	entries []RecordEntry
+	cursor  int       // next entry to play
+	clock   time.Time // recording time of the last entry played
+}
+
+// LoadRecording reads a recording written by a Recorder.
+func LoadRecording(r io.Reader) (*ReplaySource, error) {
+	s := &ReplaySource{Speed: 1}
+	dec := json.NewDecoder(r)
+	for {
+		var e RecordEntry
+		if err := dec.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading recording entry %d: %w", len(s.entries)+1, err)
+		}
+		s.entries = append(s.entries, e)
+	}
+	if len(s.entries) == 0 {
+		return nil, errors.New("recording is empty")
+	}
+	return s, nil
+}
+
+// Start and End are the times of the first and last entry.
+func (s *ReplaySource) Start() time.Time { return s.entries[0].Time }
+func (s *ReplaySource) End() time.Time   { return s.entries[len(s.entries)-1].Time }
+
+// HasMetrics reports whether this is a `top pods` recording rather than a
+// pod watch.
+func (s *ReplaySource) HasMetrics() bool {
+	return s.entries[0].Type == RecordMetrics
+}
+
+// List returns the pods as they were at Since: the last recorded list
+// before it with the events up to Since applied.
+func (s *ReplaySource) List(ctx context.Context, ns string, opts ListOpts) (*v1.PodList, error) {
+	start := -1
+	for i, e := range s.entries {
+		if e.Type == RecordList && (start < 0 || !e.Time.After(s.Since)) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, errors.New("recording has no pod list")
+	}
+
+	list := &v1.PodList{}
+	if err := json.Unmarshal(s.entries[start].Object, list); err != nil {
+		return nil, err
+	}
+	s.cursor, s.clock = start+1, s.entries[start].Time
+
+	store := newPodRowSet(list.Items)
+	for ; s.cursor < len(s.entries) && !s.entries[s.cursor].Time.After(s.Since); s.cursor++ {
+		e := s.entries[s.cursor]
+		pod := &v1.Pod{}
+		if err := json.Unmarshal(e.Object, pod); err != nil {
+			return nil, err
+		}
+		switch e.Type {
+		case EventTypeAdded, EventTypeModified:
+			store.Upsert(*pod)
+		case EventTypeDeleted:
+			store.Delete(pod.Namespace, pod.Name)
+		}
+		s.clock = e.Time
+	}
+
+	list.Items = list.Items[:0]
+	for _, r := range store.Rows() {
+		pod, _ := store.Get(r.Namespace + "/" + r.Name)
+		list.Items = append(list.Items, pod)
+	}
+	return list, nil
+}
+
+// Watch plays the events after the list with their recorded spacing. The
+// result channel is closed at the end of the recording.
+func (s *ReplaySource) Watch(ctx context.Context, ns string, opts ListOpts) (watch.Interface, error) {
+	w := &replayWatcher{result: make(chan watch.Event), done: make(chan struct{})}
+	go func() {
+		defer close(w.result)
+		for ; s.cursor < len(s.entries); s.cursor++ {
+			e := s.entries[s.cursor]
+			if e.Type == RecordList || e.Type == RecordMetrics {
+				continue
+			}
+			if !s.wait(ctx, w.done, e.Time) {
+				return
+			}
+			pod := &v1.Pod{}
+			if err := json.Unmarshal(e.Object, pod); err != nil {
+				continue
+			}
+			select {
+			case w.result <- watch.Event{Type: watch.EventType(e.Type), Object: pod}:
+			case <-w.done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+// PodUsage returns the next metrics frame, waiting for its recorded time.
+// The first call returns the frame in effect at Since right away. It
+// returns io.EOF after the last frame.
+func (s *ReplaySource) PodUsage(ctx context.Context, ns string) ([]PodMetricsRow, error) {
+	if s.clock.IsZero() {
+		// Seek to the last frame at or before Since
+		first := -1
+		for i, e := range s.entries {
+			if e.Type == RecordMetrics && (first < 0 || !e.Time.After(s.Since)) {
+				first = i
+			}
+		}
+		if first < 0 {
+			return nil, errors.New("recording has no metrics")
+		}
+		s.cursor, s.clock = first, s.entries[first].Time
+	}
+
+	for ; s.cursor < len(s.entries); s.cursor++ {
+		e := s.entries[s.cursor]
+		if e.Type != RecordMetrics {
+			continue
+		}
+		if !s.wait(ctx, nil, e.Time) {
+			return nil, ctx.Err()
+		}
+		var records []usageRecord
+		if err := json.Unmarshal(e.Object, &records); err != nil {
+			return nil, err
+		}
+		rows := make([]PodMetricsRow, len(records))
+		for i, r := range records {
+			rows[i] = r.PodMetricsRow
+			rows[i].CPUMillis, rows[i].MemoryBytes = r.CPUMillis, r.MemoryBytes
+		}
+		s.cursor++
+		return rows, nil
+	}
+	return nil, io.EOF
+}
+
+// wait sleeps until the recording time t, scaled by Speed, and reports
+// whether playback should go on.
+func (s *ReplaySource) wait(ctx context.Context, done <-chan struct{}, t time.Time) bool {
+	d := t.Sub(s.clock)
+	s.clock = t
+	if s.Speed <= 0 || d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(time.Duration(float64(d) / s.Speed))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+type replayWatcher struct {
+	result chan watch.Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (w *replayWatcher) Stop() { w.once.Do(func() { close(w.done) }) }
+
+func (w *replayWatcher) ResultChan() <-chan watch.Event { return w.result }
@@ -0,0 +1,168 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// record writes a session: a list with pod a, then b added and a deleted,
+// one second apart.
+func record(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	clock := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	rec.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	a, b := namedPod("default", "a"), namedPod("default", "b")
+	watcher := &mockWatcher{resultChan: make(chan watch.Event, 2)}
+	watcher.resultChan <- watch.Event{Type: EventTypeAdded, Object: &b}
+	watcher.resultChan <- watch.Event{Type: EventTypeDeleted, Object: &a}
+
+	src := RecordingSource{
+		Source:   &mockPodSource{listResult: &v1.PodList{Items: []v1.Pod{a}}, watchResult: watcher},
+		Recorder: rec,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := (Controller{Source: src, CurrentPrinter: &mockPrinter{}}).Run(ctx, RunOpts{Watch: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestReplaySource(t *testing.T) {
+	t.Run("full replay", func(t *testing.T) {
+		src, err := LoadRecording(record(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src.Speed = 0
+		printer := &mockPrinter{}
+		if err := (Controller{Source: src, CurrentPrinter: printer}).Run(context.Background(), RunOpts{Watch: true}); err != nil {
+			t.Fatal(err)
+		}
+		if got := rowNames(printer.lastRows); printer.refreshCount != 2 || len(got) != 1 || got[0] != "default/b" {
+			t.Errorf("replay refreshed %d times ending with %v, want 2 times ending with [default/b]", printer.refreshCount, got)
+		}
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		src, err := LoadRecording(record(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The list is at +1s and b is added at +2s
+		src.Since = src.Start().Add(time.Second)
+		list, err := src.List(context.Background(), "", ListOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Items) != 2 {
+			t.Errorf("List() at %s = %d pods, want a and b", src.Since, len(list.Items))
+		}
+	})
+
+	t.Run("speed", func(t *testing.T) {
+		src, err := LoadRecording(record(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src.Speed = 50 // two 1s gaps in 40ms
+		start := time.Now()
+		if err := (Controller{Source: src, CurrentPrinter: &mockPrinter{}}).Run(context.Background(), RunOpts{Watch: true}); err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Since(start); d < 30*time.Millisecond || d > time.Second {
+			t.Errorf("replay took %s, want about 40ms", d)
+		}
+	})
+}
+
+func TestReplaySource_Names(t *testing.T) {
+	files, err := NewFileSource(io.Discard, writeBundle(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	src := RecordingSource{Source: files, Recorder: NewRecorder(&buf)}
+	names := NameMatch{Exact: []string{"api", "web"}}
+	if err := (Controller{Source: src, CurrentPrinter: &mockPrinter{}}).Run(context.Background(), RunOpts{Names: names}); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := LoadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	printer := &mockPrinter{}
+	if err := (Controller{Source: replay, CurrentPrinter: printer}).Run(context.Background(), RunOpts{Names: names}); err != nil {
+		t.Fatal(err)
+	}
+	if got := rowNames(printer.lastRows); len(got) != 2 || got[0] != "default/web" || got[1] != "shop/api" {
+		t.Errorf("replay rows = %v, want both pods named", got)
+	}
+}
+
+type usageFunc func() []PodMetricsRow
+
+func (f usageFunc) PodUsage(context.Context, string) ([]PodMetricsRow, error) { return f(), nil }
+
+type mockMetricsPrinter struct{ frames [][]PodMetricsRow }
+
+func (m *mockMetricsPrinter) Print(rows []PodMetricsRow) error {
+	m.frames = append(m.frames, rows)
+	return nil
+}
+func (m *mockMetricsPrinter) Refresh(rows []PodMetricsRow) error { return m.Print(rows) }
+
+func TestReplaySource_Metrics(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	cpu := 0
+	src := RecordingUsageSource{
+		Source: usageFunc(func() []PodMetricsRow {
+			cpu += 100
+			return []PodMetricsRow{{Name: "api", CPU: fmt.Sprintf("%dm", cpu), CPUMillis: int64(cpu), MemoryBytes: 64 << 20}}
+		}),
+		Recorder: rec,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := (MetricsController{Source: src, Printer: &mockMetricsPrinter{}}).Run(ctx, MetricsOpts{Watch: true, Interval: 10 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := LoadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replay.HasMetrics() {
+		t.Fatal("HasMetrics() = false for a top recording")
+	}
+	replay.Speed = 0
+	printer := &mockMetricsPrinter{}
+	if err := (MetricsController{Source: replay, Printer: printer}).Run(context.Background(), MetricsOpts{Watch: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(printer.frames) < 2 || printer.frames[0][0].CPU != "100m" || printer.frames[1][0].CPU != "200m" {
+		t.Errorf("replayed frames = %v", printer.frames)
+	}
+	// Sorting and --filter need the raw usage
+	if r := printer.frames[1][0]; r.CPUMillis != 200 || r.MemoryBytes != 64<<20 {
+		t.Errorf("replayed raw usage = %d millicores, %d bytes", r.CPUMillis, r.MemoryBytes)
+	}
+}
@@ -0,0 +1,121 @@
+package kube
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// RestartEvent is a single container crash observed while watching.
+type RestartEvent struct {
+	Time      time.Time
+	Namespace string
+	Pod       string
+	Container string
+	Reason    string
+	ExitCode  int32
+	Restarts  int32
+}
+
+// RestartTracker compares the previous and new version of each modified pod
+// to find container restarts and terminations during a watch session.
+type RestartTracker struct {
+	// Log, when set, receives one line per crash
+	Log io.Writer
+
+	mu     sync.Mutex
+	counts map[string]int
+	now    func() time.Time
+}
+
+func NewRestartTracker(log io.Writer) *RestartTracker {
+	return &RestartTracker{
+		Log:    log,
+		counts: map[string]int{},
+		now:    time.Now,
+	}
+}
+
+// Observe records the crashes between old and new and returns them.
+func (t *RestartTracker) Observe(old, new *v1.Pod) ([]RestartEvent, error) {
+	prev := map[string]v1.ContainerStatus{}
+	for _, s := range old.Status.InitContainerStatuses {
+		prev[s.Name] = s
+	}
+	for _, s := range old.Status.ContainerStatuses {
+		prev[s.Name] = s
+	}
+
+	var events []RestartEvent
+	check := func(s v1.ContainerStatus) {
+		before, ok := prev[s.Name]
+		if !ok {
+			return
+		}
+		var term *v1.ContainerStateTerminated
+		switch {
+		case s.RestartCount > before.RestartCount:
+			// The crash that caused the restart is kept as the last state
+			term = s.LastTerminationState.Terminated
+		case s.State.Terminated != nil && before.State.Terminated == nil && s.State.Terminated.ExitCode != 0:
+			// Containers that are not restarted stay terminated
+			term = s.State.Terminated
+		default:
+			return
+		}
+		ev := RestartEvent{
+			Time:      t.now(),
+			Namespace: new.Namespace,
+			Pod:       new.Name,
+			Container: s.Name,
+			Reason:    "Unknown",
+			Restarts:  s.RestartCount,
+		}
+		if term != nil {
+			ev.Reason, ev.ExitCode = term.Reason, term.ExitCode
+			if !term.FinishedAt.IsZero() {
+				ev.Time = term.FinishedAt.Time
+			}
+		}
+		if n := s.RestartCount - before.RestartCount; n > 1 {
+			// Several restarts between two events: only the last one has details
+			ev.Reason = fmt.Sprintf("%s (+%d restarts)", ev.Reason, n-1)
+		}
+		events = append(events, ev)
+	}
+	for _, s := range new.Status.InitContainerStatuses {
+		check(s)
+	}
+	for _, s := range new.Status.ContainerStatuses {
+		check(s)
+	}
+
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := new.Namespace + "/" + new.Name
+	for _, ev := range events {
+		t.counts[key] += max(int(ev.Restarts-prev[ev.Container].RestartCount), 1)
+		if t.Log != nil {
+			if _, err := fmt.Fprintf(t.Log, "%s pod=%s/%s container=%s reason=%q exitCode=%d restarts=%d\n",
+				ev.Time.UTC().Format(time.RFC3339), ev.Namespace, ev.Pod, ev.Container, ev.Reason, ev.ExitCode, ev.Restarts); err != nil {
+				return events, err
+			}
+		}
+	}
+	return events, nil
+}
+
+// Recent is the number of restarts seen for the pod at key (ns/name) since
+// the session started.
+func (t *RestartTracker) Recent(key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counts[key]
+}
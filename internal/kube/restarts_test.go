@@ -0,0 +1,146 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func crashingPod(restarts int32, last *v1.ContainerStateTerminated) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{
+			Name:                 "app",
+			RestartCount:         restarts,
+			LastTerminationState: v1.ContainerState{Terminated: last},
+		}}},
+	}
+}
+
+func TestRestartTracker_Observe(t *testing.T) {
+	finished := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	oom := &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.Time{Time: finished}}
+
+	t.Run("restart count increment", func(t *testing.T) {
+		var log bytes.Buffer
+		tracker := NewRestartTracker(&log)
+
+		events, err := tracker.Observe(crashingPod(1, nil), crashingPod(2, oom))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("Observe() returned %d events, want 1", len(events))
+		}
+		ev := events[0]
+		if ev.Container != "app" || ev.Reason != "OOMKilled" || ev.ExitCode != 137 || !ev.Time.Equal(finished) {
+			t.Errorf("Observe() = %+v", ev)
+		}
+		if got := tracker.Recent("default/api"); got != 1 {
+			t.Errorf("Recent() = %d, want 1", got)
+		}
+		want := `2025-01-02T03:04:05Z pod=default/api container=app reason="OOMKilled" exitCode=137 restarts=2`
+		if strings.TrimSpace(log.String()) != want {
+			t.Errorf("restart log = %q, want %q", log.String(), want)
+		}
+	})
+
+	t.Run("several restarts between events", func(t *testing.T) {
+		tracker := NewRestartTracker(nil)
+		events, _ := tracker.Observe(crashingPod(1, nil), crashingPod(4, oom))
+		if len(events) != 1 || !strings.Contains(events[0].Reason, "+2 restarts") {
+			t.Errorf("Observe() = %+v", events)
+		}
+		if got := tracker.Recent("default/api"); got != 3 {
+			t.Errorf("Recent() = %d, want 3", got)
+		}
+	})
+
+	t.Run("terminated without restart", func(t *testing.T) {
+		tracker := NewRestartTracker(nil)
+		old := crashingPod(0, nil)
+		new := crashingPod(0, nil)
+		new.Status.ContainerStatuses[0].State.Terminated = &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 2}
+
+		events, _ := tracker.Observe(old, new)
+		if len(events) != 1 || events[0].ExitCode != 2 {
+			t.Errorf("Observe() = %+v, want one Error exit 2", events)
+		}
+	})
+
+	t.Run("no change", func(t *testing.T) {
+		tracker := NewRestartTracker(nil)
+		events, _ := tracker.Observe(crashingPod(2, oom), crashingPod(2, oom))
+		if len(events) != 0 {
+			t.Errorf("Observe() = %+v, want none", events)
+		}
+	})
+}
+
+func TestController_RunTracksRestarts(t *testing.T) {
+	watcher := &mockWatcher{resultChan: make(chan watch.Event, 1)}
+	printer := &mockPrinter{}
+	ctrl := Controller{
+		Source: &mockPodSource{
+			listResult:  &v1.PodList{Items: []v1.Pod{*crashingPod(0, nil)}},
+			watchResult: watcher,
+		},
+		CurrentPrinter: printer,
+		Restarts:       NewRestartTracker(nil),
+	}
+
+	watcher.resultChan <- watch.Event{Type: EventTypeModified, Object: crashingPod(1, &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	if err := ctrl.Run(ctx, RunOpts{Namespace: "default", Watch: true}); err != nil {
+		t.Fatalf("Controller.Run() unexpected error: %v", err)
+	}
+	if !printer.refreshCalled || len(printer.lastRows) != 1 || printer.lastRows[0].RecentRestarts != "1" {
+		t.Errorf("Controller.Run() rows = %+v, want RecentRestarts=1", printer.lastRows)
+	}
+}
+
+func TestController_RunTracksRestartsOfFilteredPods(t *testing.T) {
+	watcher := &mockWatcher{resultChan: make(chan watch.Event, 1)}
+	printer := &mockPrinter{}
+	var log bytes.Buffer
+	ctrl := Controller{
+		Source: &mockPodSource{
+			listResult:  &v1.PodList{Items: []v1.Pod{*crashingPod(0, nil)}},
+			watchResult: watcher,
+		},
+		CurrentPrinter: printer,
+		Restarts:       NewRestartTracker(&log),
+	}
+
+	// Hidden until the crash makes it match, as with --problems
+	crashed := func(p v1.Pod) bool { return p.Status.ContainerStatuses[0].RestartCount > 0 }
+	watcher.resultChan <- watch.Event{Type: EventTypeModified, Object: crashingPod(1, &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	if err := ctrl.Run(ctx, RunOpts{Namespace: "default", Watch: true, Filter: crashed}); err != nil {
+		t.Fatalf("Controller.Run() unexpected error: %v", err)
+	}
+	if len(printer.lastRows) != 1 || printer.lastRows[0].RecentRestarts != "1" {
+		t.Errorf("Controller.Run() rows = %+v, want RecentRestarts=1", printer.lastRows)
+	}
+	if !strings.Contains(log.String(), "Error") {
+		t.Errorf("restart log = %q, want the crash", log.String())
+	}
+}
@@ -0,0 +1,247 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// RolloutState is the rollout progress of a single workload, following the
+// same rules `kubectl rollout status` uses.
+type RolloutState struct {
+	Done    bool
+	Stalled bool
+	Paused  bool
+	Updated int32
+	Desired int32
+	Message string
+}
+
+var ErrRolloutStalled = errors.New("rollout stalled")
+
+func RolloutStatus(obj runtime.Object) (RolloutState, error) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return deploymentRollout(o), nil
+	case *appsv1.StatefulSet:
+		return statefulSetRollout(o)
+	case *appsv1.DaemonSet:
+		return daemonSetRollout(o)
+	}
+	return RolloutState{}, fmt.Errorf("rollout status is not supported for %T", obj)
+}
+
+func deploymentRollout(d *appsv1.Deployment) RolloutState {
+	st := RolloutState{
+		Paused:  d.Spec.Paused,
+		Updated: d.Status.UpdatedReplicas,
+		Desired: replicasOrDefault(d.Spec.Replicas),
+	}
+	if d.Generation > d.Status.ObservedGeneration {
+		st.Message = "Waiting for deployment spec update to be observed..."
+		return st
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			st.Stalled = true
+			st.Message = fmt.Sprintf("deployment %q exceeded its progress deadline", d.Name)
+			return st
+		}
+	}
+	switch {
+	case d.Status.UpdatedReplicas < st.Desired:
+		st.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...", d.Name, d.Status.UpdatedReplicas, st.Desired)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		st.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...", d.Name, d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		st.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...", d.Name, d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	default:
+		st.Done = true
+		st.Message = fmt.Sprintf("deployment %q successfully rolled out", d.Name)
+	}
+	return st
+}
+
+func statefulSetRollout(s *appsv1.StatefulSet) (RolloutState, error) {
+	st := RolloutState{
+		Updated: s.Status.UpdatedReplicas,
+		Desired: replicasOrDefault(s.Spec.Replicas),
+	}
+	if s.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return st, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+	if s.Status.ObservedGeneration == 0 || s.Generation > s.Status.ObservedGeneration {
+		st.Message = "Waiting for statefulset spec update to be observed..."
+		return st, nil
+	}
+	if s.Status.ReadyReplicas < st.Desired {
+		st.Message = fmt.Sprintf("Waiting for %d pods to be ready...", st.Desired-s.Status.ReadyReplicas)
+		return st, nil
+	}
+	if ru := s.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+		want := st.Desired - *ru.Partition
+		if s.Status.UpdatedReplicas < want {
+			st.Message = fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...", s.Status.UpdatedReplicas, want)
+			return st, nil
+		}
+		st.Done = true
+		st.Message = fmt.Sprintf("partitioned roll out complete: %d new pods have been updated...", s.Status.UpdatedReplicas)
+		return st, nil
+	}
+	if s.Status.UpdateRevision != s.Status.CurrentRevision {
+		st.Message = fmt.Sprintf("waiting for statefulset rolling update to complete %d pods at revision %s...", s.Status.UpdatedReplicas, s.Status.UpdateRevision)
+		return st, nil
+	}
+	st.Done = true
+	st.Message = fmt.Sprintf("statefulset rolling update complete %d pods at revision %s...", s.Status.CurrentReplicas, s.Status.CurrentRevision)
+	return st, nil
+}
+
+func daemonSetRollout(d *appsv1.DaemonSet) (RolloutState, error) {
+	st := RolloutState{
+		Updated: d.Status.UpdatedNumberScheduled,
+		Desired: d.Status.DesiredNumberScheduled,
+	}
+	if d.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		return st, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateDaemonSetStrategyType)
+	}
+	if d.Generation > d.Status.ObservedGeneration {
+		st.Message = "Waiting for daemon set spec update to be observed..."
+		return st, nil
+	}
+	switch {
+	case d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled:
+		st.Message = fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated...", d.Name, d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled)
+	case d.Status.NumberAvailable < d.Status.DesiredNumberScheduled:
+		st.Message = fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available...", d.Name, d.Status.NumberAvailable, d.Status.DesiredNumberScheduled)
+	default:
+		st.Done = true
+		st.Message = fmt.Sprintf("daemon set %q successfully rolled out", d.Name)
+	}
+	return st, nil
+}
+
+type RolloutWatcher struct {
+	Source WorkloadSource
+	Out    io.Writer
+}
+
+type RolloutOpts struct {
+	Namespace string
+	Ref       ObjectRef
+	Watch     bool
+	Timeout   time.Duration
+}
+
+// Run prints rollout progress until the rollout completes. A stalled or
+// deleted workload, or hitting the timeout, is reported as an error.
+func (r RolloutWatcher) Run(ctx context.Context, opts RolloutOpts) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	obj, err := r.Source.Get(ctx, opts.Ref.Kind, opts.Namespace, opts.Ref.Name)
+	if err != nil {
+		return err
+	}
+
+	last := ""
+	check := func(obj runtime.Object) (bool, error) {
+		st, err := RolloutStatus(obj)
+		if err != nil {
+			return false, err
+		}
+		if st.Message != last {
+			fmt.Fprintln(r.Out, st.Message)
+			last = st.Message
+		}
+		if st.Stalled {
+			return false, fmt.Errorf("%w: %s", ErrRolloutStalled, st.Message)
+		}
+		return st.Done, nil
+	}
+
+	done, err := check(obj)
+	if err != nil || done || !opts.Watch {
+		return err
+	}
+
+	for {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		w, err := r.Source.Watch(ctx, opts.Ref.Kind, opts.Namespace, ListOpts{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", opts.Ref.Name).String(),
+			ResourceVersion: m.GetResourceVersion(),
+		})
+		if err != nil && !isExpired(err) {
+			return err
+		}
+		if err == nil {
+			done, err := r.consume(ctx, w, opts, check)
+			w.Stop()
+			if err != nil || done {
+				return err
+			}
+		}
+
+		// The watch expired; start over from a fresh GET.
+		if obj, err = r.Source.Get(ctx, opts.Ref.Kind, opts.Namespace, opts.Ref.Name); err != nil {
+			return err
+		}
+		if done, err := check(obj); err != nil || done {
+			return err
+		}
+	}
+}
+
+func (r RolloutWatcher) consume(ctx context.Context, w watch.Interface, opts RolloutOpts, check func(runtime.Object) (bool, error)) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return false, fmt.Errorf("timed out waiting for %s rollout to finish", opts.Ref)
+			}
+			return false, ctx.Err()
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			switch ev.Type {
+			case EventTypeDeleted:
+				return false, fmt.Errorf("%s was deleted", opts.Ref)
+			case EventTypeAdded, EventTypeModified:
+				if done, err := check(ev.Object); err != nil || done {
+					return done, err
+				}
+			case watch.Error:
+				err := apierrors.FromObject(ev.Object)
+				if isExpired(err) {
+					return false, nil
+				}
+				if st, ok := ev.Object.(*metav1.Status); ok {
+					return false, fmt.Errorf("watch error: %s", st.Message)
+				}
+			}
+		}
+	}
+}
+
+// isExpired is true when the resourceVersion a watch started from is too
+// old for the apiserver to serve, which a long wait runs into.
+func isExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
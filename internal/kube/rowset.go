@@ -0,0 +1,75 @@
+package kube
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// podRowSet is the watch-mode store: the pods keyed by ns/name plus their
+// rows kept sorted by namespace and name, so an event updates a single row
+// instead of rebuilding the whole snapshot.
+type podRowSet struct {
+	pods    map[string]v1.Pod
+	entries []rowEntry
+}
+
+type rowEntry struct {
+	row     PodRow
+	created time.Time
+}
+
+func newPodRowSet(pods []v1.Pod) *podRowSet {
+	s := &podRowSet{pods: make(map[string]v1.Pod, len(pods))}
+	for _, p := range pods {
+		s.Upsert(p)
+	}
+	return s
+}
+
+func (s *podRowSet) Get(key string) (v1.Pod, bool) {
+	p, ok := s.pods[key]
+	return p, ok
+}
+
+func (s *podRowSet) Len() int { return len(s.entries) }
+
+func (s *podRowSet) Upsert(p v1.Pod) {
+	s.pods[p.Namespace+"/"+p.Name] = p
+	e := rowEntry{row: ToRows([]v1.Pod{p})[0], created: p.CreationTimestamp.Time}
+	i, found := s.search(p.Namespace, p.Name)
+	if found {
+		s.entries[i] = e
+		return
+	}
+	s.entries = slices.Insert(s.entries, i, e)
+}
+
+// Delete reports whether the pod was in the set.
+func (s *podRowSet) Delete(namespace, name string) bool {
+	delete(s.pods, namespace+"/"+name)
+	i, found := s.search(namespace, name)
+	if found {
+		s.entries = slices.Delete(s.entries, i, i+1)
+	}
+	return found
+}
+
+// Rows returns a copy of the sorted rows with AGE recomputed, since it
+// changes without any event.
+func (s *podRowSet) Rows() []PodRow {
+	rows := make([]PodRow, len(s.entries))
+	for i, e := range s.entries {
+		rows[i] = e.row
+		rows[i].Age = calcAge(e.created)
+	}
+	return rows
+}
+
+func (s *podRowSet) search(namespace, name string) (int, bool) {
+	return slices.BinarySearchFunc(s.entries, [2]string{namespace, name}, func(e rowEntry, t [2]string) int {
+		return cmp.Or(cmp.Compare(e.row.Namespace, t[0]), cmp.Compare(e.row.Name, t[1]))
+	})
+}
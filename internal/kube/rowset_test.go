@@ -0,0 +1,78 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func namedPod(ns, name string) v1.Pod {
+	return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}
+}
+
+func rowNames(rows []PodRow) []string {
+	var names []string
+	for _, r := range rows {
+		names = append(names, r.Namespace+"/"+r.Name)
+	}
+	return names
+}
+
+func TestPodRowSet(t *testing.T) {
+	set := newPodRowSet([]v1.Pod{namedPod("b", "web"), namedPod("a-b", "x"), namedPod("a", "z")})
+	set.Upsert(namedPod("a", "api"))
+
+	running := namedPod("a", "z")
+	running.Status.Phase = v1.PodRunning
+	set.Upsert(running)
+
+	if !set.Delete("b", "web") || set.Delete("b", "web") {
+		t.Error("Delete() should report true once")
+	}
+
+	rows := set.Rows()
+	want := []string{"a/api", "a/z", "a-b/x"}
+	if got := rowNames(rows); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Rows() = %v, want %v", got, want)
+	}
+	if rows[1].Status != "Running" {
+		t.Errorf("Upsert() did not replace the row: %+v", rows[1])
+	}
+	if _, ok := set.Get("b/web"); ok {
+		t.Error("Get() found a deleted pod")
+	}
+}
+
+func TestController_RunCoalescesEvents(t *testing.T) {
+	watcher := &mockWatcher{resultChan: make(chan watch.Event, 10)}
+	printer := &mockPrinter{}
+	ctrl := Controller{
+		Source: &mockPodSource{
+			listResult:  &v1.PodList{},
+			watchResult: watcher,
+		},
+		CurrentPrinter: printer,
+	}
+
+	for _, name := range []string{"c", "a", "b"} {
+		p := namedPod("default", name)
+		watcher.resultChan <- watch.Event{Type: EventTypeAdded, Object: &p}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := ctrl.Run(ctx, RunOpts{Namespace: "default", Watch: true, RefreshInterval: 50 * time.Millisecond}); err != nil {
+		t.Fatalf("Controller.Run() unexpected error: %v", err)
+	}
+
+	if printer.refreshCount != 1 {
+		t.Errorf("Refresh() called %d times, want 1", printer.refreshCount)
+	}
+	if got := rowNames(printer.lastRows); len(got) != 3 || got[0] != "default/a" || got[2] != "default/c" {
+		t.Errorf("Refresh() rows = %v, want sorted a, b, c", got)
+	}
+}
@@ -1,9 +1,12 @@
 package kube
 
 import (
+	"cmp"
 	"context"
+	"slices"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
@@ -12,10 +15,71 @@ import (
 type ClientGoSource struct{ Client kubernetes.Interface }
 
 func (s ClientGoSource) List(ctx context.Context, ns string, opts ListOpts) (*v1.PodList, error) {
-	return s.Client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+	if opts.Limit <= 0 {
+		return s.Client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: opts.LabelSelector,
+			FieldSelector: opts.FieldSelector,
+		})
+	}
+	list := &v1.PodList{}
+	rv, err := s.ListPages(ctx, ns, opts, func(pods []v1.Pod) error {
+		list.Items = append(list.Items, pods...)
+		return nil
+	})
+	list.ResourceVersion = rv
+	return list, err
+}
+
+func (s ClientGoSource) ListPages(ctx context.Context, ns string, opts ListOpts, page func([]v1.Pod) error) (string, error) {
+	return listPages(opts, page, func(listOpts metav1.ListOptions) ([]v1.Pod, metav1.ListMeta, error) {
+		list, err := s.Client.CoreV1().Pods(ns).List(ctx, listOpts)
+		if err != nil {
+			return nil, metav1.ListMeta{}, err
+		}
+		return list.Items, list.ListMeta, nil
+	})
+}
+
+// listPages lists opts.Limit pods per request, following the continue
+// token. If the token expires before the end (the server compacted the
+// snapshot) it falls back to one full list and passes on only the pods
+// after the last one already seen, relying on pods being listed in
+// namespace/name order.
+func listPages(opts ListOpts, page func([]v1.Pod) error, fetch func(metav1.ListOptions) ([]v1.Pod, metav1.ListMeta, error)) (string, error) {
+	listOpts := metav1.ListOptions{
 		LabelSelector: opts.LabelSelector,
 		FieldSelector: opts.FieldSelector,
-	})
+		Limit:         opts.Limit,
+	}
+	var last *v1.Pod
+	for {
+		pods, meta, err := fetch(listOpts)
+		if apierrors.IsResourceExpired(err) && listOpts.Continue != "" {
+			listOpts.Limit, listOpts.Continue = 0, ""
+			if pods, meta, err = fetch(listOpts); err != nil {
+				return "", err
+			}
+			if last != nil {
+				pods = slices.DeleteFunc(pods, func(p v1.Pod) bool {
+					return cmp.Or(cmp.Compare(p.Namespace, last.Namespace), cmp.Compare(p.Name, last.Name)) <= 0
+				})
+			}
+			return meta.ResourceVersion, page(pods)
+		}
+		if err != nil {
+			return "", err
+		}
+		if len(pods) > 0 {
+			last = &pods[len(pods)-1]
+		}
+		if err := page(pods); err != nil {
+			return "", err
+		}
+		if meta.Continue == "" {
+			return meta.ResourceVersion, nil
+		}
+		listOpts.Continue = meta.Continue
+	}
 }
 
 func (s ClientGoSource) Watch(ctx context.Context, ns string, opts ListOpts) (watch.Interface, error) {
@@ -0,0 +1,228 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ErrOffline is returned for operations that need a live cluster.
+var ErrOffline = errors.New("not supported when reading from files")
+
+// FileSource serves pods, events and volume claims read from manifests,
+// `kubectl get -o json|yaml` dumps or must-gather directories. It
+// implements PodSource and EvidenceSource; selectors are evaluated locally.
+type FileSource struct {
+	pods   map[string]v1.Pod
+	events []v1.Event
+	pvcs   map[string]v1.PersistentVolumeClaim
+}
+
+// NewFileSource loads every .json, .yaml and .yml file in paths, walking
+// directories recursively. Documents may be single objects or lists, and
+// files may hold several YAML documents. Objects other than pods, events
+// and claims are ignored; a pod found twice (as in must-gather, which keeps
+// both a list and one file per pod) is kept once. Documents without an
+// apiVersion and kind, such as Helm values files, are skipped with a
+// warning to log.
+func NewFileSource(log io.Writer, paths ...string) (*FileSource, error) {
+	s := &FileSource{
+		pods: map[string]v1.Pod{},
+		pvcs: map[string]v1.PersistentVolumeClaim{},
+	}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if path != root && !isManifest(path) {
+				return nil
+			}
+			loaded, skipped, err := s.loadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			switch {
+			case skipped == 0:
+			case loaded == 0:
+				fmt.Fprintf(log, "warning: skipping %s: not a Kubernetes object\n", path)
+			default:
+				fmt.Fprintf(log, "warning: skipping %d documents of %s: not Kubernetes objects\n", skipped, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func isManifest(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+func (s *FileSource) loadFile(path string) (loaded, skipped int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	return s.load(f)
+}
+
+// load adds the objects of every document in r and counts the documents
+// loaded and the ones skipped for not being Kubernetes objects.
+func (s *FileSource) load(r io.Reader) (loaded, skipped int, err error) {
+	dec := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var doc map[string]any
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			return loaded, skipped, nil
+		} else if err != nil {
+			return loaded, skipped, err
+		}
+		if doc == nil {
+			continue // empty document
+		}
+		u := &unstructured.Unstructured{Object: doc}
+		if u.GetAPIVersion() == "" || u.GetKind() == "" {
+			skipped++
+			continue
+		}
+		loaded++
+		if !u.IsList() {
+			if err := s.add(u); err != nil {
+				return loaded, skipped, err
+			}
+			continue
+		}
+		err := u.EachListItem(func(obj runtime.Object) error {
+			return s.add(obj.(*unstructured.Unstructured))
+		})
+		if err != nil {
+			return loaded, skipped, err
+		}
+	}
+}
+
+func (s *FileSource) add(u *unstructured.Unstructured) error {
+	conv := runtime.DefaultUnstructuredConverter
+	if u.GetNamespace() == "" {
+		// Like kubectl, manifests without a namespace go to default
+		u.SetNamespace(metav1.NamespaceDefault)
+	}
+	switch u.GetKind() {
+	case KindPod:
+		var pod v1.Pod
+		if err := conv.FromUnstructured(u.Object, &pod); err != nil {
+			return err
+		}
+		s.pods[pod.Namespace+"/"+pod.Name] = pod
+	case "Event":
+		var ev v1.Event
+		if err := conv.FromUnstructured(u.Object, &ev); err != nil {
+			return err
+		}
+		s.events = append(s.events, ev)
+	case "PersistentVolumeClaim":
+		var pvc v1.PersistentVolumeClaim
+		if err := conv.FromUnstructured(u.Object, &pvc); err != nil {
+			return err
+		}
+		s.pvcs[pvc.Namespace+"/"+pvc.Name] = pvc
+	}
+	return nil
+}
+
+func (s *FileSource) List(ctx context.Context, ns string, opts ListOpts) (*v1.PodList, error) {
+	labelSel, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	fieldSel, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &v1.PodList{}
+	for _, p := range s.pods {
+		if ns != "" && p.Namespace != ns {
+			continue
+		}
+		if !labelSel.Matches(labels.Set(p.Labels)) || !fieldSel.Matches(podFields(p)) {
+			continue
+		}
+		list.Items = append(list.Items, p)
+	}
+	return list, nil
+}
+
+// podFields are the pod fields the API server supports in field selectors.
+func podFields(p v1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            p.Name,
+		"metadata.namespace":       p.Namespace,
+		"spec.nodeName":            p.Spec.NodeName,
+		"spec.restartPolicy":       string(p.Spec.RestartPolicy),
+		"spec.schedulerName":       p.Spec.SchedulerName,
+		"spec.serviceAccountName":  p.Spec.ServiceAccountName,
+		"spec.hostNetwork":         fmt.Sprint(p.Spec.HostNetwork),
+		"status.phase":             string(p.Status.Phase),
+		"status.podIP":             p.Status.PodIP,
+		"status.nominatedNodeName": p.Status.NominatedNodeName,
+	}
+}
+
+func (s *FileSource) Watch(ctx context.Context, ns string, opts ListOpts) (watch.Interface, error) {
+	return nil, fmt.Errorf("watch: %w", ErrOffline)
+}
+
+func (s *FileSource) GetPod(ctx context.Context, ns, name string) (*v1.Pod, error) {
+	p, ok := s.pods[ns+"/"+name]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1.Resource("pods"), name)
+	}
+	return &p, nil
+}
+
+func (s *FileSource) PodEvents(ctx context.Context, ns, name string) ([]v1.Event, error) {
+	var events []v1.Event
+	for _, ev := range s.events {
+		o := ev.InvolvedObject
+		if o.Kind == KindPod && o.Name == name && (o.Namespace == ns || ev.Namespace == ns) {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+func (s *FileSource) GetPVC(ctx context.Context, ns, name string) (*v1.PersistentVolumeClaim, error) {
+	pvc, ok := s.pvcs[ns+"/"+name]
+	if !ok {
+		return nil, apierrors.NewNotFound(v1.Resource("persistentvolumeclaims"), name)
+	}
+	return &pvc, nil
+}
@@ -0,0 +1,192 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const mustGatherPods = `apiVersion: v1
+kind: PodList
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: api
+    namespace: shop
+    labels: {app: api}
+  spec:
+    nodeName: node-1
+  status:
+    phase: Running
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: db-0
+    namespace: shop
+    labels: {app: db}
+  spec:
+    nodeName: node-2
+    volumes:
+    - name: data
+      persistentVolumeClaim: {claimName: data-db-0}
+  status:
+    phase: Pending
+`
+
+const mustGatherPod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: api
+  namespace: shop
+  labels: {app: api}
+spec:
+  nodeName: node-1
+status:
+  phase: Running
+`
+
+const kubectlDump = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "web", "namespace": "default"}, "status": {"phase": "Running"}},
+    {"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web", "namespace": "default"}},
+    {"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "data-db-0", "namespace": "shop"}, "status": {"phase": "Pending"}}
+  ]
+}`
+
+const manifests = `apiVersion: v1
+kind: Event
+metadata: {name: db-0.1, namespace: shop}
+involvedObject: {kind: Pod, name: db-0, namespace: shop}
+reason: FailedScheduling
+message: 0/3 nodes are available
+type: Warning
+---
+# comment only
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings, namespace: shop}
+`
+
+// A manifest as written by hand, without a namespace
+const plainPod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: worker
+spec:
+  containers:
+  - name: worker
+    image: busybox
+`
+
+const helmValues = `replicaCount: 2
+image:
+  repository: nginx
+`
+
+func writeBundle(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"namespaces/shop/core/pods.yaml":    mustGatherPods,
+		"namespaces/shop/pods/api/api.yaml": mustGatherPod,
+		"namespaces/shop/pods/api/logs.txt": "not a manifest",
+		"dump.json":                         kubectlDump,
+		"namespaces/shop/core/events.yml":   manifests,
+		"pod.yaml":                          plainPod,
+		"chart/values.yaml":                 helmValues,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFileSource_List(t *testing.T) {
+	src, err := NewFileSource(io.Discard, writeBundle(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ns   string
+		opts ListOpts
+		want int
+	}{
+		{"all namespaces", "", ListOpts{}, 4},
+		{"namespace", "shop", ListOpts{}, 2},
+		{"default namespace", "default", ListOpts{}, 2},
+		{"label selector", "", ListOpts{LabelSelector: "app in (db)"}, 1},
+		{"field selector", "shop", ListOpts{FieldSelector: "status.phase!=Running"}, 1},
+		{"node selector", "", ListOpts{FieldSelector: "spec.nodeName=node-1"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := src.List(context.Background(), tt.ns, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list.Items) != tt.want {
+				t.Errorf("List() = %d pods, want %d", len(list.Items), tt.want)
+			}
+		})
+	}
+
+	if _, err := src.Watch(context.Background(), "", ListOpts{}); !errors.Is(err, ErrOffline) {
+		t.Errorf("Watch() error = %v, want ErrOffline", err)
+	}
+}
+
+func TestFileSource_Diagnose(t *testing.T) {
+	src, err := NewFileSource(io.Discard, writeBundle(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Diagnoser{Source: src}.Diagnose(context.Background(), "shop", "db-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Findings) == 0 || d.Findings[0].Reason != "PVCNotBound" {
+		t.Errorf("Diagnose() findings = %+v, want PVCNotBound first", d.Findings)
+	}
+
+	if _, err := src.GetPod(context.Background(), "shop", "missing"); !apierrors.IsNotFound(err) {
+		t.Errorf("GetPod() error = %v, want NotFound", err)
+	}
+}
+
+func TestNewFileSource_SkipsNonObjects(t *testing.T) {
+	var log bytes.Buffer
+	if _, err := NewFileSource(&log, writeBundle(t)); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.String(); !strings.Contains(got, filepath.Join("chart", "values.yaml")+": not a Kubernetes object") || strings.Count(got, "\n") != 1 {
+		t.Errorf("warnings = %q, want one for chart/values.yaml", got)
+	}
+}
+
+func TestNewFileSource_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.yaml")
+	os.WriteFile(path, []byte("kind: Pod\nmetadata: [unterminated"), 0o644)
+	if _, err := NewFileSource(io.Discard, path); err == nil {
+		t.Error("NewFileSource() error = nil for invalid YAML")
+	}
+}
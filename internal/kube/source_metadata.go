@@ -0,0 +1,63 @@
+package kube
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/metadata"
+)
+
+// MetadataPodColumns suit pods from a MetadataSource, which have no status.
+var MetadataPodColumns = []string{"NAME", "NAMESPACE", "OWNER", "LABELS", "AGE"}
+
+var podsResource = v1.SchemeGroupVersion.WithResource("pods")
+
+// MetadataSource lists pods through the metadata client, which transfers
+// only object metadata (names, labels, owners, timestamps) and is much
+// cheaper than full pods on large listings. The pods returned have an
+// empty spec and status.
+type MetadataSource struct{ Client metadata.Interface }
+
+func (s MetadataSource) List(ctx context.Context, ns string, opts ListOpts) (*v1.PodList, error) {
+	list := &v1.PodList{}
+	rv, err := s.ListPages(ctx, ns, opts, func(pods []v1.Pod) error {
+		list.Items = append(list.Items, pods...)
+		return nil
+	})
+	list.ResourceVersion = rv
+	return list, err
+}
+
+func (s MetadataSource) ListPages(ctx context.Context, ns string, opts ListOpts, page func([]v1.Pod) error) (string, error) {
+	return listPages(opts, page, func(listOpts metav1.ListOptions) ([]v1.Pod, metav1.ListMeta, error) {
+		list, err := s.Client.Resource(podsResource).Namespace(ns).List(ctx, listOpts)
+		if err != nil {
+			return nil, metav1.ListMeta{}, err
+		}
+		pods := make([]v1.Pod, 0, len(list.Items))
+		for _, m := range list.Items {
+			pods = append(pods, v1.Pod{ObjectMeta: m.ObjectMeta})
+		}
+		return pods, list.ListMeta, nil
+	})
+}
+
+func (s MetadataSource) Watch(ctx context.Context, ns string, opts ListOpts) (watch.Interface, error) {
+	w, err := s.Client.Resource(podsResource).Namespace(ns).Watch(ctx, metav1.ListOptions{
+		LabelSelector:       opts.LabelSelector,
+		FieldSelector:       opts.FieldSelector,
+		ResourceVersion:     opts.ResourceVersion,
+		AllowWatchBookmarks: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(ev watch.Event) (watch.Event, bool) {
+		if m, ok := ev.Object.(*metav1.PartialObjectMetadata); ok {
+			ev.Object = &v1.Pod{ObjectMeta: m.ObjectMeta}
+		}
+		return ev, true
+	}), nil
+}
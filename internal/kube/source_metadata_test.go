@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+)
+
+// syntheticPods builds n pods that look like a busy cluster: labels, an
+// owner, two containers with statuses.
+func syntheticPods(n int) []v1.Pod {
+	controller := true
+	pods := make([]v1.Pod, n)
+	for i := range pods {
+		pods[i] = v1.Pod{
+			TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("api-%05d", i),
+				Namespace: fmt.Sprintf("team-%d", i%20),
+				Labels:    map[string]string{"app": "api", "pod-template-hash": "7d9f8c"},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "api-7d9f8c", Controller: &controller},
+				},
+			},
+			Spec: v1.PodSpec{
+				NodeName: fmt.Sprintf("worker-%d", i%50),
+				Containers: []v1.Container{
+					{Name: "app", Image: "registry.example.com/api:1.2.3", Env: []v1.EnvVar{{Name: "MODE", Value: "prod"}}},
+					{Name: "sidecar", Image: "registry.example.com/proxy:0.9"},
+				},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				PodIP: fmt.Sprintf("10.0.%d.%d", i/250, i%250),
+				ContainerStatuses: []v1.ContainerStatus{
+					{Name: "app", Ready: true, Image: "registry.example.com/api:1.2.3"},
+					{Name: "sidecar", Ready: true, Image: "registry.example.com/proxy:0.9"},
+				},
+			},
+		}
+	}
+	return pods
+}
+
+// podServer answers pod lists the way an apiserver would: protobuf when the
+// client accepts it, JSON otherwise, and metadata only when asked for a
+// PartialObjectMetadataList.
+func podServer(tb testing.TB, pods []v1.Pod) *httptest.Server {
+	tb.Helper()
+	metaScheme := runtime.NewScheme()
+	metav1.AddMetaToScheme(metaScheme)
+
+	full := &v1.PodList{TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"}, Items: pods}
+	partial := &metav1.PartialObjectMetadataList{
+		TypeMeta: metav1.TypeMeta{Kind: "PartialObjectMetadataList", APIVersion: "meta.k8s.io/v1"},
+	}
+	for _, p := range pods {
+		partial.Items = append(partial.Items, metav1.PartialObjectMetadata{
+			TypeMeta:   metav1.TypeMeta{Kind: "PartialObjectMetadata", APIVersion: "meta.k8s.io/v1"},
+			ObjectMeta: p.ObjectMeta,
+		})
+	}
+
+	encode := func(s *runtime.Scheme, obj runtime.Object, proto bool) ([]byte, string) {
+		var enc runtime.Encoder = json.NewSerializerWithOptions(json.DefaultMetaFactory, s, s, json.SerializerOptions{})
+		contentType := "application/json"
+		if proto {
+			enc = protobuf.NewSerializer(s, s)
+			contentType = "application/vnd.kubernetes.protobuf"
+		}
+		var b bytes.Buffer
+		if err := enc.Encode(obj, &b); err != nil {
+			tb.Fatal(err)
+		}
+		return b.Bytes(), contentType
+	}
+	// Encode once up front so benchmarks measure the client side
+	bodies := map[[2]bool][]byte{}
+	types := map[[2]bool]string{}
+	for _, meta := range []bool{false, true} {
+		for _, proto := range []bool{false, true} {
+			s, obj := scheme.Scheme, runtime.Object(full)
+			if meta {
+				s, obj = metaScheme, partial
+			}
+			bodies[[2]bool{meta, proto}], types[[2]bool{meta, proto}] = encode(s, obj, proto)
+		}
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/pods" {
+			http.NotFound(w, r)
+			return
+		}
+		accept := r.Header.Get("Accept")
+		key := [2]bool{
+			strings.Contains(accept, "as=PartialObjectMetadataList"),
+			strings.HasPrefix(accept, "application/vnd.kubernetes.protobuf"),
+		}
+		w.Header().Set("Content-Type", types[key])
+		w.Write(bodies[key])
+	}))
+}
+
+func TestMetadataSource_List(t *testing.T) {
+	srv := podServer(t, syntheticPods(3))
+	defer srv.Close()
+	client, err := metadata.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := MetadataSource{Client: client}.List(context.Background(), "", ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 3 {
+		t.Fatalf("List() returned %d pods, want 3", len(list.Items))
+	}
+	p := list.Items[1]
+	if p.Name != "api-00001" || p.Namespace != "team-1" || len(p.Spec.Containers) != 0 {
+		t.Errorf("List() pod = %s/%s with %d containers, want team-1/api-00001 with metadata only", p.Namespace, p.Name, len(p.Spec.Containers))
+	}
+
+	rows := ToRows([]v1.Pod{p})
+	if rows[0].Owner != "ReplicaSet/api-7d9f8c" || rows[0].Labels != "app=api,pod-template-hash=7d9f8c" {
+		t.Errorf("ToRows() owner = %q, labels = %q", rows[0].Owner, rows[0].Labels)
+	}
+}
+
+func TestClientGoSource_Protobuf(t *testing.T) {
+	srv := podServer(t, syntheticPods(3))
+	defer srv.Close()
+	client, err := kubernetes.NewForConfig(ProtobufConfig(&rest.Config{Host: srv.URL}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := ClientGoSource{Client: client}.List(context.Background(), "", ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 3 || list.Items[2].Status.PodIP != "10.0.0.2" {
+		t.Errorf("List() = %d pods, want 3 with their status decoded", len(list.Items))
+	}
+}
+
+// The benchmarks list 5000 pods through each path:
+//
+//	go test ./internal/kube -run '^$' -bench List -benchmem
+func benchmarkList(b *testing.B, src func(*rest.Config) PodSource) {
+	srv := podServer(b, syntheticPods(5000))
+	defer srv.Close()
+	s := src(&rest.Config{Host: srv.URL})
+
+	for b.Loop() {
+		if _, err := s.List(context.Background(), "", ListOpts{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkList_ClientGoJSON(b *testing.B) {
+	benchmarkList(b, func(c *rest.Config) PodSource {
+		return ClientGoSource{Client: kubernetes.NewForConfigOrDie(c)}
+	})
+}
+
+func BenchmarkList_ClientGoProtobuf(b *testing.B) {
+	benchmarkList(b, func(c *rest.Config) PodSource {
+		return ClientGoSource{Client: kubernetes.NewForConfigOrDie(ProtobufConfig(c))}
+	})
+}
+
+func BenchmarkList_Metadata(b *testing.B) {
+	benchmarkList(b, func(c *rest.Config) PodSource {
+		client, err := metadata.NewForConfig(c)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return MetadataSource{Client: client}
+	})
+}
@@ -0,0 +1,182 @@
+package kube
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type NodeSource interface {
+	ListNodes(ctx context.Context, opts ListOpts) (*v1.NodeList, error)
+}
+
+func (s ClientGoSource) ListNodes(ctx context.Context, opts ListOpts) (*v1.NodeList, error) {
+	return s.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: opts.LabelSelector,
+		FieldSelector: opts.FieldSelector,
+	})
+}
+
+// RestartWindow is how far back restarts count as recent in the summary.
+const RestartWindow = time.Hour
+
+type ClusterStatus struct {
+	Namespaces []NamespaceStatus
+	Nodes      NodeSummary
+	TopCPU     []PodMetricsRow
+	TopMemory  []PodMetricsRow
+	// MetricsError is set when metrics-server could not be queried
+	MetricsError string `json:",omitempty"`
+}
+
+type NamespaceStatus struct {
+	Namespace      string
+	Pods           int
+	ByStatus       map[string]int
+	NotReady       int
+	RecentRestarts int
+}
+
+type NodeSummary struct {
+	Total    int
+	Ready    int
+	Problems []NodeProblem `json:",omitempty"`
+}
+
+type NodeProblem struct {
+	Name       string
+	Conditions []string
+}
+
+type StatusCollector struct {
+	Pods  PodSource
+	Nodes NodeSource
+	// Metrics is optional; without it the top consumers are left empty
+	Metrics *MetricsSource
+	// Top is how many consumers to report per resource
+	Top int
+}
+
+func (c StatusCollector) Collect(ctx context.Context, ns string) (ClusterStatus, error) {
+	var st ClusterStatus
+
+	pods, err := c.Pods.List(ctx, ns, ListOpts{})
+	if err != nil {
+		return st, err
+	}
+	st.Namespaces = summarizePods(pods.Items, time.Now())
+
+	nodes, err := c.Nodes.ListNodes(ctx, ListOpts{})
+	if err != nil {
+		return st, err
+	}
+	st.Nodes = summarizeNodes(nodes.Items)
+
+	if c.Metrics != nil {
+		usage, err := c.Metrics.PodUsage(ctx, ns)
+		if err != nil {
+			st.MetricsError = err.Error()
+		} else {
+			st.TopCPU, st.TopMemory = topConsumers(usage, c.Top)
+			if len(st.TopCPU) == 0 && len(usage) > 0 {
+				st.MetricsError = "no pod metrics available (is metrics-server running?)"
+			}
+		}
+	}
+	return st, nil
+}
+
+func summarizePods(pods []v1.Pod, now time.Time) []NamespaceStatus {
+	byNs := map[string]*NamespaceStatus{}
+	rows := ToRows(pods)
+	for i, row := range rows {
+		ns, ok := byNs[row.Namespace]
+		if !ok {
+			ns = &NamespaceStatus{Namespace: row.Namespace, ByStatus: map[string]int{}}
+			byNs[row.Namespace] = ns
+		}
+		ns.Pods++
+		ns.ByStatus[row.Status]++
+		if row.Status != "Completed" && pods[i].Status.Phase != v1.PodSucceeded && !podReady(pods[i]) {
+			ns.NotReady++
+		}
+		ns.RecentRestarts += recentRestarts(pods[i], now)
+	}
+
+	out := make([]NamespaceStatus, 0, len(byNs))
+	for _, ns := range byNs {
+		out = append(out, *ns)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Namespace < out[j].Namespace })
+	return out
+}
+
+// recentRestarts counts containers whose last termination finished within
+// RestartWindow. The API only keeps the most recent termination, so a
+// container that crashed several times in the window counts once.
+func recentRestarts(p v1.Pod, now time.Time) int {
+	n := 0
+	for _, s := range p.Status.ContainerStatuses {
+		t := s.LastTerminationState.Terminated
+		if s.RestartCount > 0 && t != nil && now.Sub(t.FinishedAt.Time) <= RestartWindow {
+			n++
+		}
+	}
+	return n
+}
+
+var nodePressureConditions = []v1.NodeConditionType{
+	v1.NodeMemoryPressure,
+	v1.NodeDiskPressure,
+	v1.NodePIDPressure,
+	v1.NodeNetworkUnavailable,
+}
+
+func summarizeNodes(nodes []v1.Node) NodeSummary {
+	sum := NodeSummary{Total: len(nodes)}
+	for _, n := range nodes {
+		var problems []string
+		ready := false
+		for _, c := range n.Status.Conditions {
+			if c.Type == v1.NodeReady {
+				ready = c.Status == v1.ConditionTrue
+				continue
+			}
+			for _, p := range nodePressureConditions {
+				if c.Type == p && c.Status == v1.ConditionTrue {
+					problems = append(problems, string(c.Type))
+				}
+			}
+		}
+		if ready {
+			sum.Ready++
+		} else {
+			problems = append([]string{"NotReady"}, problems...)
+		}
+		if n.Spec.Unschedulable {
+			problems = append(problems, "SchedulingDisabled")
+		}
+		if len(problems) > 0 {
+			sum.Problems = append(sum.Problems, NodeProblem{Name: n.Name, Conditions: problems})
+		}
+	}
+	return sum
+}
+
+func topConsumers(rows []PodMetricsRow, n int) (cpu, memory []PodMetricsRow) {
+	for _, r := range rows {
+		if r.CPU != unknownUsage {
+			cpu = append(cpu, r)
+		}
+	}
+	memory = append([]PodMetricsRow(nil), cpu...)
+	sort.SliceStable(cpu, func(i, j int) bool { return cpu[i].CPUMillis > cpu[j].CPUMillis })
+	sort.SliceStable(memory, func(i, j int) bool { return memory[i].MemoryBytes > memory[j].MemoryBytes })
+	if n > 0 && len(cpu) > n {
+		cpu, memory = cpu[:n], memory[:n]
+	}
+	return cpu, memory
+}
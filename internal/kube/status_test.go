@@ -0,0 +1,124 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func statusPod(ns, name string, ready bool, waiting string, lastCrash time.Time) *v1.Pod {
+	cs := v1.ContainerStatus{Name: "app", Ready: ready}
+	if waiting != "" {
+		cs.State.Waiting = &v1.ContainerStateWaiting{Reason: waiting}
+	} else {
+		cs.State.Running = &v1.ContainerStateRunning{}
+	}
+	if !lastCrash.IsZero() {
+		cs.RestartCount = 3
+		cs.LastTerminationState.Terminated = &v1.ContainerStateTerminated{ExitCode: 1, FinishedAt: metav1.Time{Time: lastCrash}}
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Status:     v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{cs}},
+	}
+}
+
+func podMetrics(ns, name, cpu, mem string) *metricsv1beta1.PodMetrics {
+	return &metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Containers: []metricsv1beta1.ContainerMetrics{{
+			Name: "app",
+			Usage: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse(cpu),
+				v1.ResourceMemory: resource.MustParse(mem),
+			},
+		}},
+	}
+}
+
+func TestStatusCollector_Collect(t *testing.T) {
+	now := time.Now()
+	client := fake.NewSimpleClientset(
+		statusPod("default", "web-1", true, "", time.Time{}),
+		statusPod("default", "web-2", false, "CrashLoopBackOff", now.Add(-5*time.Minute)),
+		statusPod("default", "web-3", true, "", now.Add(-3*time.Hour)),
+		statusPod("kube-system", "dns", true, "", time.Time{}),
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+			Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}},
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-2"},
+			Status: v1.NodeStatus{Conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionFalse},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue},
+			}},
+		},
+	)
+	metricsClient := metricsfake.NewSimpleClientset()
+	for _, m := range []*metricsv1beta1.PodMetrics{
+		podMetrics("default", "web-1", "200m", "64Mi"),
+		podMetrics("default", "web-3", "50m", "512Mi"),
+		podMetrics("kube-system", "dns", "10m", "16Mi"),
+	} {
+		// The metrics API serves PodMetrics under the "pods" resource
+		gvr := metricsv1beta1.SchemeGroupVersion.WithResource("pods")
+		if err := metricsClient.Tracker().Create(gvr, m, m.Namespace); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := ClientGoSource{Client: client}
+	collector := StatusCollector{
+		Pods:    source,
+		Nodes:   source,
+		Metrics: &MetricsSource{Client: client, MetricsClient: metricsClient},
+		Top:     2,
+	}
+
+	st, err := collector.Collect(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Collect() unexpected error: %v", err)
+	}
+
+	if len(st.Namespaces) != 2 {
+		t.Fatalf("Collect() namespaces = %d, want 2", len(st.Namespaces))
+	}
+	def := st.Namespaces[0]
+	if def.Namespace != "default" || def.Pods != 3 || def.NotReady != 1 || def.RecentRestarts != 1 {
+		t.Errorf("default namespace = %+v", def)
+	}
+	if def.ByStatus["Running"] != 2 || def.ByStatus["CrashLoopBackOff"] != 1 {
+		t.Errorf("default namespace status counts = %v", def.ByStatus)
+	}
+
+	if st.Nodes.Total != 2 || st.Nodes.Ready != 1 || len(st.Nodes.Problems) != 1 {
+		t.Fatalf("Collect() nodes = %+v", st.Nodes)
+	}
+	if got := strings.Join(st.Nodes.Problems[0].Conditions, ","); got != "NotReady,MemoryPressure" {
+		t.Errorf("worker-2 problems = %s", got)
+	}
+
+	if len(st.TopCPU) != 2 || st.TopCPU[0].Name != "web-1" || st.TopMemory[0].Name != "web-3" {
+		t.Errorf("Collect() top consumers cpu=%+v memory=%+v", st.TopCPU, st.TopMemory)
+	}
+
+	var buf bytes.Buffer
+	if err := NewStatusTextPrinter(&buf).Print(st); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Running:2 CrashLoopBackOff:1", "Nodes: 1/2 ready", "worker-2: NotReady, MemoryPressure", "default/web-3"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected output to contain %q\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+// PodResolver finds the pods behind a target given on the command line: a
+// pod name, pod/NAME, or an owner or service such as deploy/api or svc/web,
+// whose selector picks the pods. A label selector can stand in for the
+// target.
+type PodResolver struct {
+	Pods    PodSource
+	Dynamic dynamic.Interface
+	Mapper  meta.RESTMapper
+}
+
+// Resolve returns the matching pods, best candidates first: ready, then
+// running, then the newest.
+func (r PodResolver) Resolve(ctx context.Context, ns, target, selector string) ([]v1.Pod, error) {
+	if target != "" && selector != "" {
+		return nil, errors.New("give either a target or a selector, not both")
+	}
+	if target == "" && selector == "" {
+		return nil, errors.New("a target (POD, TYPE/NAME) or a selector is required")
+	}
+
+	if target != "" {
+		resource, name, isRef := strings.Cut(target, "/")
+		if !isRef || resource == "pod" || resource == "pods" || resource == "po" {
+			if isRef {
+				target = name
+			}
+			return r.byName(ctx, ns, target)
+		}
+		obj, err := TreeBuilder{Dynamic: r.Dynamic, Mapper: r.Mapper}.getRef(ctx, ns, target)
+		if err != nil {
+			return nil, err
+		}
+		sel, err := objectSelector(obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", target, err)
+		}
+		selector = sel.String()
+	}
+
+	list, err := r.Pods.List(ctx, ns, ListOpts{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		what := target
+		if what == "" {
+			what = "selector " + selector
+		}
+		return nil, fmt.Errorf("no pods found for %s in namespace %q", what, ns)
+	}
+	pods := list.Items
+	sortCandidates(pods)
+	return pods, nil
+}
+
+// Pick returns the best pod for the target.
+func (r PodResolver) Pick(ctx context.Context, ns, target, selector string) (*v1.Pod, error) {
+	pods, err := r.Resolve(ctx, ns, target, selector)
+	if err != nil {
+		return nil, err
+	}
+	return &pods[0], nil
+}
+
+// Owners returns the workloads behind a target: the object itself for
+// TYPE/NAME, else the topmost controller of each pod, such as the
+// Deployment above a pod's ReplicaSet. Pods sharing an owner give it once.
+func (r PodResolver) Owners(ctx context.Context, ns, target, selector string) ([]*unstructured.Unstructured, error) {
+	tree := TreeBuilder{Dynamic: r.Dynamic, Mapper: r.Mapper}
+	if resource, _, isRef := strings.Cut(target, "/"); isRef && resource != "pod" && resource != "pods" && resource != "po" {
+		obj, err := tree.getRef(ctx, ns, target)
+		if err != nil {
+			return nil, err
+		}
+		return []*unstructured.Unstructured{obj}, nil
+	}
+	pods, err := r.Resolve(ctx, ns, target, selector)
+	if err != nil {
+		return nil, err
+	}
+	var owners []*unstructured.Unstructured
+	seen := map[string]bool{}
+	for _, p := range pods {
+		ref := controllerRef(p.OwnerReferences)
+		if ref == nil {
+			return nil, fmt.Errorf("pod %s has no owner", p.Name)
+		}
+		var owner *unstructured.Unstructured
+		for ref != nil {
+			if owner, err = tree.getOwner(ctx, p.Namespace, *ref); err != nil {
+				return nil, err
+			}
+			ref = controllerRef(owner.GetOwnerReferences())
+		}
+		key := ownerKey(owner)
+		if !seen[key] {
+			seen[key] = true
+			owners = append(owners, owner)
+		}
+	}
+	sort.SliceStable(owners, func(i, j int) bool {
+		return ownerKey(owners[i]) < ownerKey(owners[j])
+	})
+	return owners, nil
+}
+
+func ownerKey(o *unstructured.Unstructured) string {
+	return o.GetNamespace() + "/" + o.GetKind() + "/" + o.GetName()
+}
+
+func (r PodResolver) byName(ctx context.Context, ns, name string) ([]v1.Pod, error) {
+	list, err := getByName(ctx, r.Pods, ns, ListOpts{}, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// PodSelector returns the label selector of the pods of a workload or
+// service.
+func PodSelector(obj *unstructured.Unstructured) (string, error) {
+	sel, err := objectSelector(obj)
+	if err != nil {
+		return "", fmt.Errorf("%s/%s: %w", strings.ToLower(obj.GetKind()), obj.GetName(), err)
+	}
+	return sel.String(), nil
+}
+
+// objectSelector reads the pod selector of a workload (spec.selector as a
+// LabelSelector) or a service (spec.selector as a map).
+func objectSelector(obj *unstructured.Unstructured) (labels.Selector, error) {
+	raw, found, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "selector")
+	if err != nil || !found {
+		return nil, fmt.Errorf("%s has no pod selector", obj.GetKind())
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return nil, fmt.Errorf("%s has no pod selector", obj.GetKind())
+	}
+	_, hasLabels := m["matchLabels"]
+	_, hasExprs := m["matchExpressions"]
+	if !hasLabels && !hasExprs {
+		// A service selects with a plain map
+		set := labels.Set{}
+		for k, v := range m {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s has an invalid selector", obj.GetKind())
+			}
+			set[k] = s
+		}
+		return labels.SelectorFromSet(set), nil
+	}
+	var ls metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &ls); err != nil {
+		return nil, err
+	}
+	return metav1.LabelSelectorAsSelector(&ls)
+}
+
+func sortCandidates(pods []v1.Pod) {
+	rank := func(p v1.Pod) int {
+		switch {
+		case p.DeletionTimestamp != nil:
+			return 3
+		case podReady(p):
+			return 0
+		case p.Status.Phase == v1.PodRunning:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		if ri, rj := rank(pods[i]), rank(pods[j]); ri != rj {
+			return ri < rj
+		}
+		return pods[j].CreationTimestamp.Before(&pods[i].CreationTimestamp)
+	})
+}
@@ -0,0 +1,109 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestResolver(pods ...v1.Pod) PodResolver {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+
+	objs := []runtime.Object{
+		newUnstructured("apps/v1", "Deployment", "api", nil, map[string]interface{}{
+			"spec": map[string]interface{}{"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "api"},
+			}},
+		}),
+		newUnstructured("v1", "Service", "web", nil, map[string]interface{}{
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"app": "web"},
+				"ports": []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(80), "targetPort": "web"},
+					map[string]interface{}{"name": "metrics", "port": int64(9090)},
+				},
+			},
+		}),
+		newUnstructured("v1", "Service", "external", nil, map[string]interface{}{
+			"spec": map[string]interface{}{"type": "ExternalName"},
+		}),
+	}
+	var podObjs []runtime.Object
+	for i := range pods {
+		podObjs = append(podObjs, &pods[i])
+	}
+	return PodResolver{
+		Pods:    ClientGoSource{Client: fake.NewClientset(podObjs...)},
+		Dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objs...),
+		Mapper:  mapper,
+	}
+}
+
+func labeledPod(name, app string, ready bool, age time.Duration) v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "default", Labels: map[string]string{"app": app},
+			CreationTimestamp: metav1.Time{Time: time.Now().Add(-age)},
+		},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestPodResolver_Pick(t *testing.T) {
+	r := newTestResolver(
+		labeledPod("api-old", "api", true, time.Hour),
+		labeledPod("api-new", "api", true, time.Minute),
+		labeledPod("api-unready", "api", false, time.Second),
+		labeledPod("web-1", "web", false, time.Hour),
+	)
+
+	tests := []struct {
+		target, selector string
+		want             string
+	}{
+		{"web-1", "", "web-1"},
+		{"pod/api-old", "", "api-old"},
+		{"deployments/api", "", "api-new"}, // ready first, then the newest
+		{"services/web", "", "web-1"},
+		{"", "app=api", "api-new"},
+	}
+	for _, tt := range tests {
+		pod, err := r.Pick(context.Background(), "default", tt.target, tt.selector)
+		if err != nil {
+			t.Errorf("Pick(%q, %q) error = %v", tt.target, tt.selector, err)
+			continue
+		}
+		if pod.Name != tt.want {
+			t.Errorf("Pick(%q, %q) = %s, want %s", tt.target, tt.selector, pod.Name, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ target, selector, want string }{
+		{"missing", "", "not found"},
+		{"services/external", "", "no pod selector"},
+		{"", "app=db", "no pods found"},
+		{"", "", "required"},
+	} {
+		if _, err := r.Pick(context.Background(), "default", tt.target, tt.selector); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Pick(%q, %q) error = %v, want it to mention %q", tt.target, tt.selector, err, tt.want)
+		}
+	}
+}
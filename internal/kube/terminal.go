@@ -0,0 +1,150 @@
+package kube
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// terminalFd returns the file descriptor of w when it is an interactive
+// terminal.
+func terminalFd(w io.Writer) (int, bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0, false
+	}
+	fd := int(f.Fd())
+	return fd, term.IsTerminal(fd)
+}
+
+// terminalSize returns 0, 0 when the size is unknown.
+func terminalSize(fd int) (width, height int) {
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		return 0, 0
+	}
+	return width, height
+}
+
+// fitColumns truncates cells in place so a table with these columns is at
+// most width characters wide. The widest column is shrunk first, and never
+// below its header. NAME is cut in the middle so the generated pod suffix
+// stays visible.
+func fitColumns(cols []string, data [][]string, width int) {
+	if width <= 0 {
+		return
+	}
+	widths := make([]int, len(cols))
+	for i, c := range cols {
+		widths[i] = len([]rune(c))
+	}
+	for _, cells := range data {
+		for i, c := range cells {
+			widths[i] = max(widths[i], len([]rune(c)))
+		}
+	}
+
+	// Each column is padded with a space on both sides plus one border
+	excess := -width + 3*len(cols) + 1
+	for _, w := range widths {
+		excess += w
+	}
+	for excess > 0 {
+		widest := -1
+		for i, w := range widths {
+			if w > len([]rune(cols[i])) && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			// Even the headers don't fit; let the terminal wrap
+			break
+		}
+		widths[widest]--
+		excess--
+	}
+
+	for _, cells := range data {
+		for i, c := range cells {
+			cells[i] = truncate(c, widths[i], cols[i] == "NAME")
+		}
+	}
+}
+
+func truncate(s string, n int, middle bool) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(r[:n])
+	}
+	if !middle {
+		return string(r[:n-1]) + "…"
+	}
+	tail := (n - 1) / 2
+	head := n - 1 - tail
+	return string(r[:head]) + "…" + string(r[len(r)-tail:])
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// MakeRaw puts the terminal f in raw mode for an interactive session. It
+// returns false when f is not a terminal.
+func MakeRaw(f *os.File) (restore func(), ok bool, err error) {
+	fd := int(f.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, false, nil
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, false, err
+	}
+	return func() { term.Restore(fd, state) }, true, nil
+}
+
+type sizeQueue struct {
+	sizes chan remotecommand.TerminalSize
+}
+
+func (q *sizeQueue) Next() *remotecommand.TerminalSize {
+	s, ok := <-q.sizes
+	if !ok {
+		return nil
+	}
+	return &s
+}
+
+// NewTerminalSizeQueue reports the size of the terminal f now and after
+// every resize, until ctx is done.
+func NewTerminalSizeQueue(ctx context.Context, f *os.File) remotecommand.TerminalSizeQueue {
+	fd := int(f.Fd())
+	q := &sizeQueue{sizes: make(chan remotecommand.TerminalSize, 1)}
+	resized := notifyResize(ctx)
+	go func() {
+		defer close(q.sizes)
+		var last remotecommand.TerminalSize
+		for {
+			if w, h := terminalSize(fd); w > 0 && (w != int(last.Width) || h != int(last.Height)) {
+				last = remotecommand.TerminalSize{Width: uint16(w), Height: uint16(h)}
+				select {
+				case q.sizes <- last:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-resized:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return q
+}
@@ -0,0 +1,85 @@
+package kube
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in     string
+		n      int
+		middle bool
+		want   string
+	}{
+		{"short", 10, false, "short"},
+		{"ContainerCreating", 8, false, "Contain…"},
+		{"api-7d9f8c6b5-x2k4q", 11, true, "api-7…x2k4q"},
+		{"abc", 1, true, "a"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.n, tt.middle); got != tt.want {
+			t.Errorf("truncate(%q, %d, %v) = %q, want %q", tt.in, tt.n, tt.middle, got, tt.want)
+		}
+	}
+}
+
+func TestFitColumns(t *testing.T) {
+	cols := []string{"NAME", "STATUS"}
+	data := [][]string{{"checkout-service-7d9f8c6b5-x2k4q", "CrashLoopBackOff"}}
+
+	// 3*2+1 characters of padding and borders leave 23 for the cells
+	fitColumns(cols, data, 30)
+	if got := len([]rune(data[0][0])) + len([]rune(data[0][1])); got != 23 {
+		t.Errorf("fitColumns() cells = %q, total width %d, want 23", data[0], got)
+	}
+	if !strings.HasSuffix(data[0][0], "x2k4q") {
+		t.Errorf("fitColumns() NAME = %q, want the pod suffix kept", data[0][0])
+	}
+
+	// Headers are never truncated
+	data = [][]string{{"checkout", "Running"}}
+	fitColumns(cols, data, 5)
+	if data[0][0] != "ch…t" || data[0][1] != "Runni…" {
+		t.Errorf("fitColumns() = %q, want cells cut to the header widths", data[0])
+	}
+}
+
+func TestLiveTablePrinter_Terminal(t *testing.T) {
+	rows := []PodRow{
+		{Name: "a", Namespace: "default", Status: "Running"},
+		{Name: "b", Namespace: "default", Status: "Running"},
+		{Name: "c", Namespace: "default", Status: "Running"},
+	}
+
+	t.Run("not a terminal", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := NewLiveTablePrinter(&buf)
+		p.Print(rows)
+		p.Refresh(rows)
+		out := buf.String()
+		if strings.Contains(out, "\x1b[") {
+			t.Errorf("non-TTY output contains escape codes: %q", out)
+		}
+		if strings.Count(out, "│ NAME ") != 2 {
+			t.Errorf("non-TTY output should append both snapshots:\n%s", out)
+		}
+	})
+
+	t.Run("taller than the terminal", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := NewLiveTablePrinter(&buf)
+		p.Columns = []string{"NAME", "STATUS"}
+		p.tty = true
+		p.size = func() (int, int) { return 80, 8 }
+		p.Print(rows)
+		out := buf.String()
+		if !strings.Contains(out, "│ b ") || strings.Contains(out, "│ c ") {
+			t.Errorf("expected rows a and b only:\n%s", out)
+		}
+		if !strings.Contains(out, "1 more pods not shown") {
+			t.Errorf("missing overflow indicator:\n%s", out)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package kube
+
+import (
+	"context"
+	"time"
+)
+
+// notifyResize polls, as Windows has no resize signal; the caller only acts
+// when the size changed.
+func notifyResize(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	go func() {
+		t := time.NewTicker(250 * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
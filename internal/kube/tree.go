@@ -0,0 +1,351 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// NoOwnerKind marks the synthetic root that groups pods without a live owner.
+const NoOwnerKind = "(no owner)"
+
+type TreeNode struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Ready     string
+	Status    string
+	Age       string
+	Note      string      `json:",omitempty"`
+	Children  []*TreeNode `json:",omitempty"`
+}
+
+type TreeBuilder struct {
+	Pods    PodSource
+	Dynamic dynamic.Interface
+	Mapper  meta.RESTMapper
+}
+
+type TreeOpts struct {
+	Namespace string
+	ListOpts  ListOpts
+	// Root is an optional TYPE/NAME to show the tree below; when empty every
+	// pod in the namespace is walked up to its top-level owner. Roots with a
+	// pod selector only walk up from the pods and owners it matches.
+	Root string
+}
+
+// intermediateResources are listed when walking down from a workload so that
+// owners without any pods (e.g. scaled down ReplicaSets) still show up.
+var intermediateResources = []schema.GroupVersionResource{
+	{Group: "apps", Version: "v1", Resource: "replicasets"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+}
+
+type treeWalk struct {
+	b        TreeBuilder
+	ctx      context.Context
+	nodes    map[string]*TreeNode
+	parent   map[string]string
+	children map[string][]string
+}
+
+func (b TreeBuilder) Build(ctx context.Context, opts TreeOpts) ([]*TreeNode, error) {
+	t := &treeWalk{
+		b:      b,
+		ctx:    ctx,
+		nodes:  map[string]*TreeNode{},
+		parent: map[string]string{},
+	}
+
+	var rootKey string
+	listOpts := opts.ListOpts
+	if opts.Root != "" {
+		root, err := b.getRef(ctx, opts.Namespace, opts.Root)
+		if err != nil {
+			return nil, err
+		}
+		rootKey = t.add(root)
+		// A CronJob has no selector of its own, its tree comes from
+		// everything in the namespace.
+		var selector string
+		if sel, err := objectSelector(root); err == nil {
+			selector = sel.String()
+			listOpts.LabelSelector = andSelectors(listOpts.LabelSelector, selector)
+		}
+		for _, gvr := range intermediateResources {
+			list, err := b.Dynamic.Resource(gvr).Namespace(opts.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				// The cluster may not serve this resource; pods still work.
+				continue
+			}
+			for i := range list.Items {
+				if len(list.Items[i].GetOwnerReferences()) > 0 {
+					t.walkUp(&list.Items[i])
+				}
+			}
+		}
+	}
+
+	pods, err := b.Pods.List(ctx, opts.Namespace, listOpts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		t.walkUp(&pods.Items[i])
+	}
+
+	t.children = map[string][]string{}
+	for child, parent := range t.parent {
+		t.children[parent] = append(t.children[parent], child)
+	}
+
+	if rootKey != "" {
+		return []*TreeNode{t.link(rootKey)}, nil
+	}
+
+	var roots []*TreeNode
+	orphans := &TreeNode{Kind: NoOwnerKind}
+	for key, n := range t.nodes {
+		if _, ok := t.parent[key]; ok {
+			continue
+		}
+		if n.Kind == KindPod {
+			orphans.Children = append(orphans.Children, t.link(key))
+			continue
+		}
+		roots = append(roots, t.link(key))
+	}
+	sortNodes(roots)
+	if len(orphans.Children) > 0 {
+		sortNodes(orphans.Children)
+		roots = append(roots, orphans)
+	}
+	return roots, nil
+}
+
+// andSelectors joins two label selectors into one matching both.
+func andSelectors(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "," + b
+}
+
+// add records obj as a node and returns its key.
+func (t *treeWalk) add(obj runtime.Object) string {
+	n := newTreeNode(obj)
+	key := n.Kind + "/" + n.Namespace + "/" + n.Name
+	if _, ok := t.nodes[key]; !ok {
+		t.nodes[key] = n
+	}
+	return key
+}
+
+// walkUp follows the controlling owner references of obj until it reaches an
+// object without owners or one that has already been visited.
+func (t *treeWalk) walkUp(obj runtime.Object) {
+	key := t.add(obj)
+	for {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return
+		}
+		ref := controllerRef(m.GetOwnerReferences())
+		if ref == nil {
+			return
+		}
+		parentKey := ref.Kind + "/" + m.GetNamespace() + "/" + ref.Name
+		if _, seen := t.nodes[parentKey]; seen {
+			t.parent[key] = parentKey
+			return
+		}
+
+		owner, err := t.b.getOwner(t.ctx, m.GetNamespace(), *ref)
+		if apierrors.IsNotFound(err) {
+			t.nodes[key].Note = fmt.Sprintf("owner %s/%s not found", ref.Kind, ref.Name)
+			return
+		}
+		if err != nil {
+			// Keep the owner as a placeholder so the tree still renders.
+			t.nodes[parentKey] = &TreeNode{Kind: ref.Kind, Name: ref.Name, Namespace: m.GetNamespace(), Note: err.Error()}
+			t.parent[key] = parentKey
+			return
+		}
+
+		ownerKey := t.add(owner)
+		t.parent[key] = ownerKey
+		key, obj = ownerKey, owner
+	}
+}
+
+// link resolves children for the node at key and returns it.
+func (t *treeWalk) link(key string) *TreeNode {
+	n := t.nodes[key]
+	n.Children = nil
+	for _, child := range t.children[key] {
+		n.Children = append(n.Children, t.link(child))
+	}
+	sortNodes(n.Children)
+	return n
+}
+
+func controllerRef(refs []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return &refs[i]
+		}
+	}
+	if len(refs) > 0 {
+		return &refs[0]
+	}
+	return nil
+}
+
+func (b TreeBuilder) getOwner(ctx context.Context, ns string, ref metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := b.Mapper.RESTMapping(gv.WithKind(ref.Kind).GroupKind(), gv.Version)
+	if err != nil {
+		return nil, err
+	}
+	return b.get(ctx, mapping, ns, ref.Name)
+}
+
+// getRef fetches the object named by a TYPE/NAME reference of any kind.
+func (b TreeBuilder) getRef(ctx context.Context, ns, ref string) (*unstructured.Unstructured, error) {
+	resource, name, ok := strings.Cut(ref, "/")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("expected TYPE/NAME, got %q", ref)
+	}
+	gvk, err := b.Mapper.KindFor(schema.GroupVersionResource{Resource: resource})
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := b.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	return b.get(ctx, mapping, ns, name)
+}
+
+func (b TreeBuilder) get(ctx context.Context, mapping *meta.RESTMapping, ns, name string) (*unstructured.Unstructured, error) {
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return b.Dynamic.Resource(mapping.Resource).Get(ctx, name, metav1.GetOptions{})
+	}
+	return b.Dynamic.Resource(mapping.Resource).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+}
+
+func newTreeNode(obj runtime.Object) *TreeNode {
+	n := &TreeNode{}
+	if m, err := meta.Accessor(obj); err == nil {
+		n.Name = m.GetName()
+		n.Namespace = m.GetNamespace()
+		n.Age = calcAge(m.GetCreationTimestamp().Time)
+	}
+
+	switch o := obj.(type) {
+	case *v1.Pod:
+		row := ToRows([]v1.Pod{*o})[0]
+		n.Kind, n.Ready, n.Status = KindPod, row.Ready, row.Status
+	case *unstructured.Unstructured:
+		n.Kind = o.GetKind()
+		n.Ready, n.Status = unstructuredStatus(o)
+	}
+	return n
+}
+
+// unstructuredStatus summarises readiness for owners fetched through the
+// dynamic client. Known workloads reuse the typed rollout rules.
+func unstructuredStatus(u *unstructured.Unstructured) (string, string) {
+	var typed runtime.Object
+	switch u.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Group: "apps", Kind: KindDeployment}:
+		typed = &appsv1.Deployment{}
+	case schema.GroupKind{Group: "apps", Kind: KindStatefulSet}:
+		typed = &appsv1.StatefulSet{}
+	case schema.GroupKind{Group: "apps", Kind: KindDaemonSet}:
+		typed = &appsv1.DaemonSet{}
+	}
+	if typed != nil && runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed) == nil {
+		if row, ok := toWorkloadRow(typed); ok {
+			return row.Ready, row.Rollout
+		}
+	}
+
+	obj := u.Object
+	if desired, found, _ := unstructured.NestedInt64(obj, "spec", "replicas"); found {
+		ready, _, _ := unstructured.NestedInt64(obj, "status", "readyReplicas")
+		return fmt.Sprintf("%d/%d", ready, desired), ""
+	}
+
+	switch u.GetKind() {
+	case "Job":
+		succeeded, _, _ := unstructured.NestedInt64(obj, "status", "succeeded")
+		completions, found, _ := unstructured.NestedInt64(obj, "spec", "completions")
+		if !found {
+			completions = 1
+		}
+		status := "Running"
+		if c := conditionStatus(obj, "Complete"); c == "True" {
+			status = "Complete"
+		} else if c := conditionStatus(obj, "Failed"); c == "True" {
+			status = "Failed"
+		}
+		return fmt.Sprintf("%d/%d", succeeded, completions), status
+	case "CronJob":
+		if suspend, _, _ := unstructured.NestedBool(obj, "spec", "suspend"); suspend {
+			return "", "Suspended"
+		}
+		active, _, _ := unstructured.NestedSlice(obj, "status", "active")
+		return "", fmt.Sprintf("Active %d", len(active))
+	}
+
+	switch conditionStatus(obj, "Ready") {
+	case "True":
+		return "", "Ready"
+	case "False":
+		return "", "NotReady"
+	}
+	return "", ""
+}
+
+func conditionStatus(obj map[string]interface{}, condType string) string {
+	conds, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	for _, c := range conds {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if m["type"] == condType {
+			s, _ := m["status"].(string)
+			return s
+		}
+	}
+	return ""
+}
+
+func sortNodes(nodes []*TreeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Kind != nodes[j].Kind {
+			return nodes[i].Kind < nodes[j].Kind
+		}
+		if nodes[i].Namespace != nodes[j].Namespace {
+			return nodes[i].Namespace < nodes[j].Namespace
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+}
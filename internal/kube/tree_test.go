@@ -0,0 +1,173 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func ownedBy(apiVersion, kind, name string) []metav1.OwnerReference {
+	t := true
+	return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, Controller: &t}}
+}
+
+func newUnstructured(apiVersion, kind, name string, owners []metav1.OwnerReference, fields map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for k, v := range fields {
+		u.Object[k] = v
+	}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName(name)
+	u.SetNamespace("default")
+	u.SetOwnerReferences(owners)
+	return u
+}
+
+func newTestTreeBuilder(pods []v1.Pod) TreeBuilder {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}, meta.RESTScopeNamespace)
+
+	objs := []runtime.Object{
+		newUnstructured("apps/v1", "Deployment", "api", nil, map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": int64(2), "selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "api"},
+			}},
+			"status": map[string]interface{}{"replicas": int64(2), "readyReplicas": int64(1), "updatedReplicas": int64(2), "availableReplicas": int64(1)},
+		}),
+		newUnstructured("apps/v1", "ReplicaSet", "api-7d9f", ownedBy("apps/v1", "Deployment", "api"), map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "api"}},
+			"spec":     map[string]interface{}{"replicas": int64(2)},
+			"status":   map[string]interface{}{"readyReplicas": int64(1)},
+		}),
+		newUnstructured("apps/v1", "ReplicaSet", "api-old", ownedBy("apps/v1", "Deployment", "api"), map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "api"}},
+			"spec":     map[string]interface{}{"replicas": int64(0)},
+		}),
+		newUnstructured("batch/v1", "CronJob", "backup", nil, nil),
+		newUnstructured("batch/v1", "Job", "backup-123", ownedBy("batch/v1", "CronJob", "backup"), map[string]interface{}{
+			"status": map[string]interface{}{"succeeded": int64(1), "conditions": []interface{}{
+				map[string]interface{}{"type": "Complete", "status": "True"},
+			}},
+		}),
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "apps", Version: "v1", Resource: "replicasets"}: "ReplicaSetList",
+		{Group: "batch", Version: "v1", Resource: "jobs"}:       "JobList",
+	}, objs...)
+
+	return TreeBuilder{
+		Pods:    &mockPodSource{listResult: &v1.PodList{Items: pods}},
+		Dynamic: dyn,
+		Mapper:  mapper,
+	}
+}
+
+func treePod(name string, owners []metav1.OwnerReference) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: owners},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestTreeBuilder_Build(t *testing.T) {
+	pods := []v1.Pod{
+		treePod("api-7d9f-a", ownedBy("apps/v1", "ReplicaSet", "api-7d9f")),
+		treePod("api-7d9f-b", ownedBy("apps/v1", "ReplicaSet", "api-7d9f")),
+		treePod("backup-123-x", ownedBy("batch/v1", "Job", "backup-123")),
+		treePod("standalone", nil),
+		treePod("ghost", ownedBy("apps/v1", "ReplicaSet", "gone")),
+	}
+
+	t.Run("walks up from every pod", func(t *testing.T) {
+		roots, err := newTestTreeBuilder(pods).Build(context.Background(), TreeOpts{Namespace: "default"})
+		if err != nil {
+			t.Fatalf("Build() unexpected error: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := NewTreeTextPrinter(&buf).Print(roots); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+
+		for _, want := range []string{
+			"CronJob/backup",
+			"└── Job/backup-123",
+			"Deployment/api",
+			"ReplicaSet/api-7d9f",
+			"├── Pod/api-7d9f-a",
+			NoOwnerKind,
+			"Pod/standalone",
+			"owner ReplicaSet/gone not found",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected tree to contain %q\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "api-old") {
+			t.Errorf("ReplicaSets without pods should only show when walking down\n%s", out)
+		}
+
+		if len(roots) != 3 || roots[2].Kind != NoOwnerKind || len(roots[2].Children) != 2 {
+			t.Errorf("Build() roots = %d, want CronJob, Deployment and 2 pods without an owner", len(roots))
+		}
+	})
+
+	t.Run("walks down from a workload", func(t *testing.T) {
+		roots, err := newTestTreeBuilder(pods).Build(context.Background(), TreeOpts{Namespace: "default", Root: "deployment/api"})
+		if err != nil {
+			t.Fatalf("Build() unexpected error: %v", err)
+		}
+		if len(roots) != 1 {
+			t.Fatalf("Build() returned %d roots, want 1", len(roots))
+		}
+		dep := roots[0]
+		if dep.Kind != "Deployment" || dep.Ready != "1/2" {
+			t.Errorf("root = %+v, want Deployment with 1/2 ready", dep)
+		}
+		if len(dep.Children) != 2 {
+			t.Fatalf("Deployment has %d children, want both ReplicaSets", len(dep.Children))
+		}
+		if rs := dep.Children[0]; rs.Name != "api-7d9f" || len(rs.Children) != 2 {
+			t.Errorf("ReplicaSet api-7d9f = %+v, want 2 pods", rs)
+		}
+	})
+
+	t.Run("walks up only from the pods of the root", func(t *testing.T) {
+		api := treePod("api-7d9f-a", ownedBy("apps/v1", "ReplicaSet", "api-7d9f"))
+		api.Labels = map[string]string{"app": "api"}
+		backup := treePod("backup-123-x", ownedBy("batch/v1", "Job", "backup-123"))
+		b := newTestTreeBuilder(nil)
+		b.Pods = ClientGoSource{Client: fake.NewClientset(&api, &backup)}
+
+		if _, err := b.Build(context.Background(), TreeOpts{Namespace: "default", Root: "deployment/api"}); err != nil {
+			t.Fatalf("Build() unexpected error: %v", err)
+		}
+		for _, a := range b.Dynamic.(*dynamicfake.FakeDynamicClient).Actions() {
+			if a.GetResource().Resource == "jobs" && a.GetVerb() == "get" {
+				t.Errorf("Build() looked up a Job outside deployment/api: %v", a)
+			}
+		}
+	})
+
+	t.Run("unknown root", func(t *testing.T) {
+		_, err := newTestTreeBuilder(pods).Build(context.Background(), TreeOpts{Namespace: "default", Root: "deployment/missing"})
+		if err == nil {
+			t.Error("Build() expected error for missing root")
+		}
+	})
+}
@@ -0,0 +1,242 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RestartedAtAnnotation is the pod template annotation `kubectl rollout
+// restart` sets; changing it rolls every pod.
+const RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// Workloads changes workloads of any kind through the dynamic client.
+type Workloads struct {
+	Dynamic dynamic.Interface
+	Mapper  meta.RESTMapper
+}
+
+func (w Workloads) resource(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := w.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return w.Dynamic.Resource(mapping.Resource), nil
+	}
+	return w.Dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
+
+// Restart rolls the pods of a Deployment, StatefulSet or DaemonSet by
+// stamping its pod template with the time.
+func (w Workloads) Restart(ctx context.Context, obj *unstructured.Unstructured, at time.Time) error {
+	switch obj.GetKind() {
+	case KindDeployment, KindStatefulSet, KindDaemonSet:
+	default:
+		return fmt.Errorf("cannot restart %s %s: only deployments, statefulsets and daemonsets can be restarted", strings.ToLower(obj.GetKind()), obj.GetName())
+	}
+	if paused, _, _ := unstructured.NestedBool(obj.Object, "spec", "paused"); paused {
+		return fmt.Errorf("cannot restart %s %s: it is paused", strings.ToLower(obj.GetKind()), obj.GetName())
+	}
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{"template": map[string]any{"metadata": map[string]any{
+			"annotations": map[string]string{RestartedAtAnnotation: at.Format(time.RFC3339)},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	res, err := w.resource(obj)
+	if err != nil {
+		return err
+	}
+	_, err = res.Patch(ctx, obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// Scale sets the replicas through the scale subresource, which any
+// scalable kind has, custom resources included. It returns the replicas
+// before the change and the pod selector the scale reports in
+// status.selector, which custom resources may not have in their spec.
+func (w Workloads) Scale(ctx context.Context, obj *unstructured.Unstructured, replicas int32) (int32, string, error) {
+	res, err := w.resource(obj)
+	if err != nil {
+		return 0, "", err
+	}
+	scale, err := res.Get(ctx, obj.GetName(), metav1.GetOptions{}, "scale")
+	if err != nil {
+		return 0, "", fmt.Errorf("%s %s cannot be scaled: %w", strings.ToLower(obj.GetKind()), obj.GetName(), err)
+	}
+	previous, _, _ := unstructured.NestedInt64(scale.Object, "spec", "replicas")
+	patch, err := json.Marshal(map[string]any{"spec": map[string]any{"replicas": replicas}})
+	if err != nil {
+		return 0, "", err
+	}
+	scaled, err := res.Patch(ctx, obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}, "scale")
+	if err != nil {
+		return int32(previous), "", err
+	}
+	selector, _, _ := unstructured.NestedString(scaled.Object, "status", "selector")
+	return int32(previous), selector, nil
+}
+
+// ParseResourceList reads cpu=500m,memory=256Mi.
+func ParseResourceList(s string) (v1.ResourceList, error) {
+	if s == "" {
+		return nil, nil
+	}
+	list := v1.ResourceList{}
+	for _, kv := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid resource %q: want NAME=QUANTITY", kv)
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource %q: %w", kv, err)
+		}
+		list[v1.ResourceName(name)] = q
+	}
+	return list, nil
+}
+
+// ResizePod changes a container's resources in place through the pod's
+// resize subresource, without restarting the pod.
+func ResizePod(ctx context.Context, client kubernetes.Interface, pod *v1.Pod, container string, requests, limits v1.ResourceList) error {
+	resources := map[string]v1.ResourceList{}
+	if len(requests) > 0 {
+		resources["requests"] = requests
+	}
+	if len(limits) > 0 {
+		resources["limits"] = limits
+	}
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{"containers": []map[string]any{{"name": container, "resources": resources}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "resize")
+	return err
+}
+
+// RestartSettled is true once every pod carries the restart's stamp on
+// its RestartedAtAnnotation, which pods inherit from the template, and is
+// ready. Matching the stamp rather than creation times keeps it right when
+// the local clock is off from the apiserver's.
+func RestartSettled(at time.Time) func([]v1.Pod) (bool, error) {
+	stamp := at.Format(time.RFC3339)
+	return func(pods []v1.Pod) (bool, error) {
+		pods = liveWorkloadPods(pods)
+		if len(pods) == 0 {
+			return false, nil
+		}
+		for _, p := range pods {
+			if p.Annotations[RestartedAtAnnotation] != stamp || !podReady(p) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+// ScaleSettled is true once there are exactly replicas pods, all ready and
+// none terminating.
+func ScaleSettled(replicas int32) func([]v1.Pod) (bool, error) {
+	return func(pods []v1.Pod) (bool, error) {
+		pods = liveWorkloadPods(pods)
+		if len(pods) != int(replicas) {
+			return false, nil
+		}
+		for _, p := range pods {
+			if p.DeletionTimestamp != nil || !podReady(p) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+// liveWorkloadPods leaves out pods that have finished, such as evicted
+// ones, which linger but no longer count towards the workload.
+func liveWorkloadPods(pods []v1.Pod) []v1.Pod {
+	return slices.DeleteFunc(slices.Clone(pods), func(p v1.Pod) bool {
+		return p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed
+	})
+}
+
+// ResizeSettled is true once the kubelet has applied the resize of each
+// pod, given by namespace/name with the container resized. A resize the
+// node cannot fit is an error.
+func ResizeSettled(containers map[string]string) func([]v1.Pod) (bool, error) {
+	return func(pods []v1.Pod) (bool, error) {
+		byName := map[string]v1.Pod{}
+		for _, p := range pods {
+			byName[p.Namespace+"/"+p.Name] = p
+		}
+		for name, container := range containers {
+			p, ok := byName[name]
+			if !ok {
+				return false, fmt.Errorf("pod %s went away during the resize", name)
+			}
+			done, err := resized(p, container)
+			if err != nil || !done {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+func resized(p v1.Pod, container string) (bool, error) {
+	if p.Status.ObservedGeneration != 0 && p.Status.ObservedGeneration < p.Generation {
+		return false, nil
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Status != v1.ConditionTrue {
+			continue
+		}
+		switch {
+		case c.Type == v1.PodResizePending && c.Reason == v1.PodReasonInfeasible:
+			return false, fmt.Errorf("cannot resize pod %s: %s", p.Name, c.Message)
+		case c.Type == v1.PodResizePending, c.Type == v1.PodResizeInProgress:
+			return false, nil
+		}
+	}
+	var want v1.ResourceRequirements
+	for _, c := range p.Spec.Containers {
+		if c.Name == container {
+			want = c.Resources
+		}
+	}
+	for _, s := range p.Status.ContainerStatuses {
+		if s.Name != container || s.Resources == nil {
+			continue
+		}
+		return sameResources(want.Requests, s.Resources.Requests) && sameResources(want.Limits, s.Resources.Limits), nil
+	}
+	// Kubelets that do not report resources: trust the conditions
+	return true, nil
+}
+
+func sameResources(want, got v1.ResourceList) bool {
+	for name, q := range want {
+		if g, ok := got[name]; !ok || g.Cmp(q) != 0 {
+			return false
+		}
+	}
+	return true
+}
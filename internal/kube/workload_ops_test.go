@@ -0,0 +1,208 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPodResolver_Owners(t *testing.T) {
+	pods := []v1.Pod{
+		treePod("api-7d9f-a", ownedBy("apps/v1", "ReplicaSet", "api-7d9f")),
+		treePod("api-7d9f-b", ownedBy("apps/v1", "ReplicaSet", "api-7d9f")),
+		treePod("backup-123-x", ownedBy("batch/v1", "Job", "backup-123")),
+		treePod("standalone", nil),
+	}
+	for i := range pods {
+		pods[i].Labels = map[string]string{"tier": "backend"}
+	}
+	pods[3].Labels = nil
+	tb := newTestTreeBuilder(pods)
+	r := PodResolver{Pods: ClientGoSource{Client: fake.NewClientset(&pods[0], &pods[1], &pods[2], &pods[3])}, Dynamic: tb.Dynamic, Mapper: tb.Mapper}
+
+	names := func(owners []*unstructured.Unstructured) string {
+		var s []string
+		for _, o := range owners {
+			s = append(s, o.GetKind()+"/"+o.GetName())
+		}
+		return strings.Join(s, " ")
+	}
+	owners, err := r.Owners(context.Background(), "default", "", "tier=backend")
+	if err != nil || names(owners) != "CronJob/backup Deployment/api" {
+		t.Errorf("Owners(tier=backend) = %s, %v", names(owners), err)
+	}
+	owners, err = r.Owners(context.Background(), "default", "api-7d9f-a", "")
+	if err != nil || names(owners) != "Deployment/api" {
+		t.Errorf("Owners(pod) = %s, %v", names(owners), err)
+	}
+	owners, err = r.Owners(context.Background(), "default", "replicasets/api-7d9f", "")
+	if err != nil || names(owners) != "ReplicaSet/api-7d9f" {
+		t.Errorf("Owners(TYPE/NAME) = %s, %v", names(owners), err)
+	}
+	if _, err := r.Owners(context.Background(), "default", "standalone", ""); err == nil || !strings.Contains(err.Error(), "has no owner") {
+		t.Errorf("Owners(standalone) error = %v", err)
+	}
+
+	// The same deployment name in another namespace is another owner
+	staging := pods[0]
+	staging.Namespace = "staging"
+	dyn := tb.Dynamic.(*dynamicfake.FakeDynamicClient)
+	for _, o := range []*unstructured.Unstructured{
+		newUnstructured("apps/v1", "Deployment", "api", nil, nil),
+		newUnstructured("apps/v1", "ReplicaSet", "api-7d9f", ownedBy("apps/v1", "Deployment", "api"), nil),
+	} {
+		o.SetNamespace("staging")
+		if err := dyn.Tracker().Add(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.Pods = ClientGoSource{Client: fake.NewClientset(&pods[0], &staging)}
+	owners, err = r.Owners(context.Background(), "", "", "tier=backend")
+	if err != nil || len(owners) != 2 || owners[0].GetNamespace() != "default" || owners[1].GetNamespace() != "staging" {
+		t.Errorf("Owners(-A) = %s, %v; want Deployment/api in default and staging", names(owners), err)
+	}
+}
+
+func TestWorkloads_RestartAndScale(t *testing.T) {
+	deploy := newUnstructured("apps/v1", "Deployment", "api", nil, map[string]interface{}{
+		"spec":   map[string]interface{}{"replicas": int64(2)},
+		"status": map[string]interface{}{"selector": "app=api"},
+	})
+	dyn := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), deploy)
+	var patches []string
+	dyn.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		p := action.(k8stesting.PatchAction)
+		patches = append(patches, p.GetSubresource()+" "+string(p.GetPatch()))
+		return false, nil, nil
+	})
+	w := Workloads{Dynamic: dyn, Mapper: newTestResolver().Mapper}
+
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := w.Restart(context.Background(), deploy, at); err != nil {
+		t.Fatal(err)
+	}
+	previous, selector, err := w.Scale(context.Background(), deploy, 5)
+	if err != nil || previous != 2 || selector != "app=api" {
+		t.Errorf("Scale() = %d, %q, %v; want 2, app=api", previous, selector, err)
+	}
+	want := []string{
+		` {"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":"2026-05-01T12:00:00Z"}}}}}`,
+		`scale {"spec":{"replicas":5}}`,
+	}
+	if strings.Join(patches, "\n") != strings.Join(want, "\n") {
+		t.Errorf("patches:\n%s\nwant:\n%s", strings.Join(patches, "\n"), strings.Join(want, "\n"))
+	}
+
+	job := newUnstructured("batch/v1", "Job", "backup", nil, nil)
+	if err := w.Restart(context.Background(), job, at); err == nil || !strings.Contains(err.Error(), "only deployments") {
+		t.Errorf("Restart(job) error = %v", err)
+	}
+}
+
+func TestSettled(t *testing.T) {
+	at := time.Now()
+	oldPod := labeledPod("api-old", "api", true, time.Hour)
+	// Created by an apiserver whose clock is behind the local one
+	newPod := labeledPod("api-new", "api", true, time.Minute)
+	newPod.Annotations = map[string]string{RestartedAtAnnotation: at.Format(time.RFC3339)}
+	starting := labeledPod("api-new2", "api", false, 0)
+	starting.Annotations = newPod.Annotations
+	evicted := labeledPod("api-evicted", "api", false, 2*time.Hour)
+	evicted.Status.Phase, evicted.Status.Reason = v1.PodFailed, "Evicted"
+
+	restarted := RestartSettled(at)
+	for _, tt := range []struct {
+		pods []v1.Pod
+		want bool
+	}{
+		{[]v1.Pod{oldPod, newPod}, false},
+		{[]v1.Pod{newPod, starting}, false},
+		{[]v1.Pod{newPod}, true},
+		{[]v1.Pod{evicted, newPod}, true},
+		{nil, false},
+	} {
+		if got, _ := restarted(tt.pods); got != tt.want {
+			t.Errorf("RestartSettled(%v) = %v, want %v", podNames(tt.pods), got, tt.want)
+		}
+	}
+
+	terminating := newPod
+	terminating.DeletionTimestamp = &metav1.Time{Time: at}
+	scaled := ScaleSettled(2)
+	if ok, _ := scaled([]v1.Pod{oldPod, terminating}); ok {
+		t.Error("ScaleSettled(2) with a terminating pod = true")
+	}
+	if ok, _ := scaled([]v1.Pod{oldPod, newPod}); !ok {
+		t.Error("ScaleSettled(2) with two ready pods = false")
+	}
+	if ok, _ := scaled([]v1.Pod{evicted, oldPod, newPod}); !ok {
+		t.Error("ScaleSettled(2) with two ready pods and an evicted one = false")
+	}
+	if ok, _ := ScaleSettled(0)(nil); !ok {
+		t.Error("ScaleSettled(0) with no pods = false")
+	}
+}
+
+func TestResizeSettled(t *testing.T) {
+	pod := labeledPod("api-1", "api", true, time.Hour)
+	pod.Generation = 2
+	pod.Status.ObservedGeneration = 2
+	pod.Spec.Containers = []v1.Container{{Name: "app", Resources: v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+	}}}
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", Resources: &v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m")},
+	}}}
+	settled := ResizeSettled(map[string]string{"default/api-1": "app"})
+	// A pod of the same name in another namespace does not stand in for it
+	other := pod
+	other.Namespace = "staging"
+	if _, err := settled([]v1.Pod{other}); err == nil || !strings.Contains(err.Error(), "went away") {
+		t.Errorf("settled without the pod error = %v", err)
+	}
+
+	if ok, err := settled([]v1.Pod{pod}); ok || err != nil {
+		t.Errorf("settled before the kubelet applied it = %v, %v", ok, err)
+	}
+	pod.Status.ContainerStatuses[0].Resources.Requests[v1.ResourceCPU] = resource.MustParse("0.5")
+	if ok, err := settled([]v1.Pod{pod}); !ok || err != nil {
+		t.Errorf("settled after = %v, %v", ok, err)
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, v1.PodCondition{
+		Type: v1.PodResizePending, Status: v1.ConditionTrue, Reason: v1.PodReasonInfeasible, Message: "Node didn't have enough capacity: cpu",
+	})
+	if _, err := settled([]v1.Pod{pod}); err == nil || !strings.Contains(err.Error(), "enough capacity") {
+		t.Errorf("settled with an infeasible resize error = %v", err)
+	}
+}
+
+func TestResizePod(t *testing.T) {
+	pod := labeledPod("api-1", "api", true, time.Hour)
+	client := fake.NewClientset(&pod)
+	var got string
+	client.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		p := action.(k8stesting.PatchAction)
+		got = p.GetSubresource() + " " + string(p.GetPatch())
+		return true, &pod, nil
+	})
+	requests, _ := ParseResourceList("cpu=500m,memory=256Mi")
+	if err := ResizePod(context.Background(), client, &pod, "app", requests, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := `resize {"spec":{"containers":[{"name":"app","resources":{"requests":{"cpu":"500m","memory":"256Mi"}}}]}}`; got != want {
+		t.Errorf("patch = %s, want %s", got, want)
+	}
+	if _, err := ParseResourceList("cpu"); err == nil {
+		t.Error("ParseResourceList(cpu) error = nil")
+	}
+}
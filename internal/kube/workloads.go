@@ -0,0 +1,261 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+type WorkloadSource interface {
+	List(ctx context.Context, kind, ns string, opts ListOpts) ([]runtime.Object, error)
+	Get(ctx context.Context, kind, ns, name string) (runtime.Object, error)
+	Watch(ctx context.Context, kind, ns string, opts ListOpts) (watch.Interface, error)
+}
+
+type WorkloadRow struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Desired   string
+	Current   string
+	Ready     string
+	UpToDate  string
+	Available string
+	Rollout   string
+	Age       string
+}
+
+type ClientGoWorkloadSource struct{ Client kubernetes.Interface }
+
+func (s ClientGoWorkloadSource) List(ctx context.Context, kind, ns string, opts ListOpts) ([]runtime.Object, error) {
+	lo := metav1.ListOptions{
+		LabelSelector: opts.LabelSelector,
+		FieldSelector: opts.FieldSelector,
+	}
+	var objs []runtime.Object
+	switch kind {
+	case KindDeployment:
+		list, err := s.Client.AppsV1().Deployments(ns).List(ctx, lo)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+	case KindStatefulSet:
+		list, err := s.Client.AppsV1().StatefulSets(ns).List(ctx, lo)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+	case KindDaemonSet:
+		list, err := s.Client.AppsV1().DaemonSets(ns).List(ctx, lo)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+	return objs, nil
+}
+
+func (s ClientGoWorkloadSource) Get(ctx context.Context, kind, ns, name string) (runtime.Object, error) {
+	switch kind {
+	case KindDeployment:
+		return s.Client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	case KindStatefulSet:
+		return s.Client.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+	case KindDaemonSet:
+		return s.Client.AppsV1().DaemonSets(ns).Get(ctx, name, metav1.GetOptions{})
+	}
+	return nil, fmt.Errorf("unsupported workload kind %q", kind)
+}
+
+func (s ClientGoWorkloadSource) Watch(ctx context.Context, kind, ns string, opts ListOpts) (watch.Interface, error) {
+	lo := metav1.ListOptions{
+		LabelSelector:   opts.LabelSelector,
+		FieldSelector:   opts.FieldSelector,
+		ResourceVersion: opts.ResourceVersion,
+	}
+	switch kind {
+	case KindDeployment:
+		return s.Client.AppsV1().Deployments(ns).Watch(ctx, lo)
+	case KindStatefulSet:
+		return s.Client.AppsV1().StatefulSets(ns).Watch(ctx, lo)
+	case KindDaemonSet:
+		return s.Client.AppsV1().DaemonSets(ns).Watch(ctx, lo)
+	}
+	return nil, fmt.Errorf("unsupported workload kind %q", kind)
+}
+
+func ToWorkloadRows(objs []runtime.Object) []WorkloadRow {
+	rows := make([]WorkloadRow, 0, len(objs))
+	for _, obj := range objs {
+		if row, ok := toWorkloadRow(obj); ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func toWorkloadRow(obj runtime.Object) (WorkloadRow, bool) {
+	var row WorkloadRow
+	var desired, current, ready, upToDate, available int32
+
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		row.Kind = KindDeployment
+		desired = replicasOrDefault(o.Spec.Replicas)
+		current = o.Status.Replicas
+		ready = o.Status.ReadyReplicas
+		upToDate = o.Status.UpdatedReplicas
+		available = o.Status.AvailableReplicas
+	case *appsv1.StatefulSet:
+		row.Kind = KindStatefulSet
+		desired = replicasOrDefault(o.Spec.Replicas)
+		current = o.Status.Replicas
+		ready = o.Status.ReadyReplicas
+		upToDate = o.Status.UpdatedReplicas
+		available = o.Status.AvailableReplicas
+	case *appsv1.DaemonSet:
+		row.Kind = KindDaemonSet
+		desired = o.Status.DesiredNumberScheduled
+		current = o.Status.CurrentNumberScheduled
+		ready = o.Status.NumberReady
+		upToDate = o.Status.UpdatedNumberScheduled
+		available = o.Status.NumberAvailable
+	default:
+		return row, false
+	}
+
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return row, false
+	}
+	row.Name = m.GetName()
+	row.Namespace = m.GetNamespace()
+	row.Age = calcAge(m.GetCreationTimestamp().Time)
+	row.Desired = fmt.Sprintf("%d", desired)
+	row.Current = fmt.Sprintf("%d", current)
+	row.Ready = fmt.Sprintf("%d/%d", ready, desired)
+	row.UpToDate = fmt.Sprintf("%d", upToDate)
+	row.Available = fmt.Sprintf("%d", available)
+	row.Rollout = rolloutColumn(obj)
+	return row, true
+}
+
+func rolloutColumn(obj runtime.Object) string {
+	st, err := RolloutStatus(obj)
+	switch {
+	case err != nil:
+		return "-"
+	case st.Paused:
+		return "Paused"
+	case st.Stalled:
+		return "Stalled"
+	case st.Done:
+		return "Complete"
+	case st.Desired > 0:
+		return fmt.Sprintf("Progressing %d/%d", st.Updated, st.Desired)
+	default:
+		return "Progressing"
+	}
+}
+
+func replicasOrDefault(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
+
+type WorkloadController struct {
+	Source  WorkloadSource
+	Printer WorkloadPrinter
+}
+
+type WorkloadOpts struct {
+	Kind      string
+	Namespace string
+	ListOpts  ListOpts
+	Watch     bool
+}
+
+func (c WorkloadController) Run(ctx context.Context, opts WorkloadOpts) error {
+	objs, err := c.Source.List(ctx, opts.Kind, opts.Namespace, opts.ListOpts)
+	if err != nil {
+		return err
+	}
+	if err := c.Printer.Print(ToWorkloadRows(objs)); err != nil {
+		return err
+	}
+	if !opts.Watch {
+		return nil
+	}
+
+	w, err := c.Source.Watch(ctx, opts.Kind, opts.Namespace, opts.ListOpts)
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	// Keep a local store keyed by ns/name, preserving list order
+	store := map[string]runtime.Object{}
+	var keys []string
+	for _, obj := range objs {
+		key := objectKey(obj)
+		store[key] = obj
+		keys = append(keys, key)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			key := objectKey(ev.Object)
+			switch ev.Type {
+			case EventTypeAdded, EventTypeModified:
+				if _, ok := store[key]; !ok {
+					keys = append(keys, key)
+				}
+				store[key] = ev.Object
+			case EventTypeDeleted:
+				delete(store, key)
+				keys = slices.DeleteFunc(keys, func(k string) bool { return k == key })
+			default:
+				continue
+			}
+			snap := make([]runtime.Object, 0, len(keys))
+			for _, k := range keys {
+				snap = append(snap, store[k])
+			}
+			if err := c.Printer.Refresh(ToWorkloadRows(snap)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func objectKey(obj runtime.Object) string {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return m.GetNamespace() + "/" + m.GetName()
+}
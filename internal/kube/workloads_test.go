@@ -0,0 +1,247 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func newDeployment(name string, replicas, updated, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Generation:        2,
+			CreationTimestamp: metav1.Time{Time: time.Now()},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(replicas)},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           replicas,
+			UpdatedReplicas:    updated,
+			ReadyReplicas:      available,
+			AvailableReplicas:  available,
+		},
+	}
+}
+
+func TestRolloutStatus(t *testing.T) {
+	stalled := newDeployment("api", 3, 1, 1)
+	stalled.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentProgressing,
+		Reason: "ProgressDeadlineExceeded",
+	}}
+
+	unobserved := newDeployment("api", 3, 3, 3)
+	unobserved.Generation = 3
+
+	onDelete := &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+	}
+
+	partitioned := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Generation: 1},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: int32Ptr(3),
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: int32Ptr(1)},
+			},
+		},
+		Status: appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 2},
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent"},
+		Spec: appsv1.DaemonSetSpec{
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+		},
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 4, UpdatedNumberScheduled: 4, NumberAvailable: 2},
+	}
+
+	tests := []struct {
+		name        string
+		obj         runtime.Object
+		wantDone    bool
+		wantStalled bool
+		wantErr     bool
+		wantMsg     string
+	}{
+		{name: "deployment complete", obj: newDeployment("api", 3, 3, 3), wantDone: true, wantMsg: "successfully rolled out"},
+		{name: "deployment updating", obj: newDeployment("api", 3, 1, 1), wantMsg: "1 out of 3 new replicas"},
+		{name: "deployment waiting for availability", obj: newDeployment("api", 3, 3, 2), wantMsg: "2 of 3 updated replicas are available"},
+		{name: "deployment stalled", obj: stalled, wantStalled: true, wantMsg: "exceeded its progress deadline"},
+		{name: "deployment spec not observed", obj: unobserved, wantMsg: "spec update to be observed"},
+		{name: "statefulset on delete", obj: onDelete, wantErr: true},
+		{name: "statefulset partitioned", obj: partitioned, wantDone: true, wantMsg: "partitioned roll out complete"},
+		{name: "daemonset unavailable", obj: ds, wantMsg: "2 of 4 updated pods are available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := RolloutStatus(tt.obj)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("RolloutStatus() expected error, got %+v", st)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RolloutStatus() unexpected error: %v", err)
+			}
+			if st.Done != tt.wantDone || st.Stalled != tt.wantStalled {
+				t.Errorf("RolloutStatus() done=%v stalled=%v, want done=%v stalled=%v", st.Done, st.Stalled, tt.wantDone, tt.wantStalled)
+			}
+			if !strings.Contains(st.Message, tt.wantMsg) {
+				t.Errorf("RolloutStatus() message = %q, want it to contain %q", st.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestToWorkloadRows(t *testing.T) {
+	rows := ToWorkloadRows([]runtime.Object{newDeployment("api", 3, 1, 1)})
+	if len(rows) != 1 {
+		t.Fatalf("ToWorkloadRows() returned %d rows, want 1", len(rows))
+	}
+	r := rows[0]
+	if r.Kind != KindDeployment || r.Desired != "3" || r.Ready != "1/3" || r.UpToDate != "1" || r.Rollout != "Progressing 1/3" {
+		t.Errorf("ToWorkloadRows() = %+v", r)
+	}
+}
+
+func TestRolloutWatcher_Run(t *testing.T) {
+	t.Run("waits until complete", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newDeployment("api", 3, 1, 1))
+		var out bytes.Buffer
+		watcher := RolloutWatcher{Source: ClientGoWorkloadSource{Client: client}, Out: &out}
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			client.AppsV1().Deployments("default").UpdateStatus(context.Background(), newDeployment("api", 3, 3, 3), metav1.UpdateOptions{})
+		}()
+
+		err := watcher.Run(context.Background(), RolloutOpts{
+			Namespace: "default",
+			Ref:       ObjectRef{Kind: KindDeployment, Name: "api"},
+			Watch:     true,
+			Timeout:   time.Second,
+		})
+		if err != nil {
+			t.Fatalf("RolloutWatcher.Run() unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), "successfully rolled out") {
+			t.Errorf("expected completion message, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("expired watch starts over", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newDeployment("api", 3, 1, 1))
+		watches := 0
+		client.PrependWatchReactor("deployments", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			watches++
+			if watches > 1 {
+				return false, nil, nil
+			}
+			// The rollout finishes while the watch is too old to say so
+			client.Tracker().Update(appsv1.SchemeGroupVersion.WithResource("deployments"), newDeployment("api", 3, 3, 3), "default")
+			w := watch.NewRaceFreeFake()
+			w.Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+			return true, w, nil
+		})
+		var out bytes.Buffer
+		watcher := RolloutWatcher{Source: ClientGoWorkloadSource{Client: client}, Out: &out}
+
+		err := watcher.Run(context.Background(), RolloutOpts{
+			Namespace: "default",
+			Ref:       ObjectRef{Kind: KindDeployment, Name: "api"},
+			Watch:     true,
+			Timeout:   time.Second,
+		})
+		if err != nil || !strings.Contains(out.String(), "successfully rolled out") {
+			t.Errorf("RolloutWatcher.Run() = %v, output:\n%s", err, out.String())
+		}
+	})
+
+	t.Run("stalled rollout fails", func(t *testing.T) {
+		d := newDeployment("api", 3, 1, 1)
+		d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"}}
+		client := fake.NewSimpleClientset(d)
+		watcher := RolloutWatcher{Source: ClientGoWorkloadSource{Client: client}, Out: &bytes.Buffer{}}
+
+		err := watcher.Run(context.Background(), RolloutOpts{
+			Namespace: "default",
+			Ref:       ObjectRef{Kind: KindDeployment, Name: "api"},
+			Watch:     true,
+		})
+		if !errors.Is(err, ErrRolloutStalled) {
+			t.Errorf("RolloutWatcher.Run() error = %v, want ErrRolloutStalled", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newDeployment("api", 3, 1, 1))
+		watcher := RolloutWatcher{Source: ClientGoWorkloadSource{Client: client}, Out: &bytes.Buffer{}}
+
+		err := watcher.Run(context.Background(), RolloutOpts{
+			Namespace: "default",
+			Ref:       ObjectRef{Kind: KindDeployment, Name: "api"},
+			Watch:     true,
+			Timeout:   30 * time.Millisecond,
+		})
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("RolloutWatcher.Run() error = %v, want timeout", err)
+		}
+	})
+}
+
+func TestParseObjectRef(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    ObjectRef
+		wantErr bool
+	}{
+		{args: []string{"deploy/api"}, want: ObjectRef{Kind: KindDeployment, Name: "api"}},
+		{args: []string{"statefulset", "db"}, want: ObjectRef{Kind: KindStatefulSet, Name: "db"}},
+		{args: []string{"ds/agent"}, want: ObjectRef{Kind: KindDaemonSet, Name: "agent"}},
+		{args: []string{"api"}, wantErr: true},
+		{args: []string{"cronjob/x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			got, err := ParseObjectRef(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseObjectRef(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseObjectRef(%v) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadTablePrinter(t *testing.T) {
+	var out bytes.Buffer
+	if err := (WorkloadTablePrinter{Writer: &out}).Print(nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "UP-TO-DATE") {
+		t.Errorf("header should keep UP-TO-DATE as is:\n%s", out.String())
+	}
+}
@@ -1,7 +1,21 @@
 package main
 
-import "github.com/massanaRoger/kube-peek/cmd"
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/massanaRoger/kube-peek/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	if err := cmd.Execute(); err != nil {
+		// A remote command that failed passes on its exit code
+		var exit interface{ ExitStatus() int }
+		if errors.As(err, &exit) {
+			os.Exit(exit.ExitStatus())
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
 }